@@ -69,8 +69,25 @@ func main() {
 	paymentService := service.NewPaymentService(pool, queries, cfg)
 	promoService := service.NewPromoService(pool, queries)
 	premiumService := service.NewPremiumService(pool, queries)
-	openRouter := service.NewOpenRouterService(cfg.OpenRouterKey)
+	aliasService := service.NewModelAliasService(queries)
+	openRouter := service.NewOpenRouterService(cfg.OpenRouterKey, cfg.OpenRouterURL)
+	providers := []service.LLMProvider{openRouter}
+	if cfg.OpenAICompatEnabled {
+		providers = append(providers, service.NewOpenAICompatService(cfg))
+	}
+	catalog := service.NewModelCatalog(queries, providers...)
+	if err := catalog.Load(ctx); err != nil {
+		slog.Error("failed to load model catalogue", "error", err)
+	}
+	llm := service.NewLLMRouter(catalog, service.NewCircuitBreaker(config.CircuitFailureThreshold, config.CircuitOpenDuration))
+	fallbackService := service.NewFallbackService(queries, llm)
+	replacementService := service.NewModelReplacementService(pool, queries, llm)
+	toolRegistry := service.NewToolRegistry(cfg, queries)
+	transcriptionService := service.NewTranscriptionService(llm, cfg.STTModel)
+	ttsService := service.NewTTSService(cfg)
+	summaryService := service.NewSummaryService(pool, queries, llm, cfg.SummaryModel)
 	skysmartService := service.NewSkysmartService()
+	reconciler := service.NewCostReconciler(pool, queries, openRouter)
 
 	// Handler pointer for use in default handler closure
 	var h *handler.Handler
@@ -92,6 +109,15 @@ func main() {
 				h.HandlePreCheckout(ctx, b, update)
 				return
 			}
+			if update.InlineQuery != nil {
+				h.HandleInlineQuery(ctx, b, update)
+				return
+			}
+			// Edited prompts are answered again
+			if update.EditedMessage != nil {
+				h.HandleEditedPrivate(ctx, b, update)
+				return
+			}
 		}),
 	}
 
@@ -121,14 +147,22 @@ func main() {
 		GroupService:    groupService,
 		SessionService:  sessionService,
 		BillingService:  billingService,
+		FallbackService: fallbackService,
+		Replacements:    replacementService,
+		ToolRegistry:    toolRegistry,
+		Transcription:   transcriptionService,
+		TTS:             ttsService,
+		Summary:         summaryService,
 		PaymentService:  paymentService,
 		PromoService:    promoService,
 		PremiumService:  premiumService,
-		OpenRouter:      openRouter,
+		Aliases:         aliasService,
+		Reconciler:      reconciler,
+		LLM:             llm,
 		SkysmartService: skysmartService,
 		Queries:         queries,
 		TgLogger:        tgLogger,
-		BotUsername:      me.Username,
+		BotUsername:     me.Username,
 	})
 
 	// Register all handlers
@@ -145,6 +179,10 @@ func main() {
 		}
 		// Route to appropriate text handler
 		if update.Message.Chat.Type == "private" {
+			if update.Message.Voice != nil || update.Message.Audio != nil || update.Message.VideoNote != nil {
+				h.HandleVoicePrivate(ctx, b, update)
+				return
+			}
 			h.HandleTextPrivate(ctx, b, update)
 		} else {
 			h.HandleTextGroup(ctx, b, update)
@@ -167,6 +205,47 @@ func main() {
 		}
 	}()
 
+	// Start model catalogue refresh goroutine. The stored catalogue may be
+	// old, so it is refreshed right away. Removed models are only replaced
+	// after a refresh where every provider answered.
+	go func() {
+		refresh := func() {
+			if err := catalog.Refresh(ctx); err != nil {
+				slog.Error("refresh model catalogue", "error", err)
+				return
+			}
+			h.MigrateRemovedModels(ctx)
+		}
+		refresh()
+
+		ticker := time.NewTicker(config.ModelRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+
+	// Start cost reconciliation goroutine
+	go func() {
+		ticker := time.NewTicker(config.CostReconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := reconciler.Reconcile(ctx); err != nil {
+					slog.Error("reconcile costs", "error", err)
+				}
+			}
+		}
+	}()
+
 	// Start bot
 	slog.Info("starting bot", "username", me.Username, "id", me.ID)
 	b.Start(ctx)
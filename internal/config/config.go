@@ -12,6 +12,36 @@ type Config struct {
 	BotToken      string `env:"BOT_TOKEN,required"`
 	DatabaseURL   string `env:"DATABASE_URL,required"`
 	OpenRouterKey string `env:"OPENROUTER_API_KEY,required"`
+	OpenRouterURL string `env:"OPENROUTER_API_URL" envDefault:"https://openrouter.ai/api/v1"`
+
+	// LLM provider: any OpenAI-compatible API (e.g. local vLLM/Ollama server)
+	OpenAICompatEnabled         bool    `env:"OPENAI_COMPAT_ENABLED" envDefault:"false"`
+	OpenAICompatName            string  `env:"OPENAI_COMPAT_NAME" envDefault:"local"`
+	OpenAICompatBaseURL         string  `env:"OPENAI_COMPAT_BASE_URL" envDefault:"http://localhost:11434/v1"`
+	OpenAICompatAPIKey          string  `env:"OPENAI_COMPAT_API_KEY"`
+	OpenAICompatPromptPrice     float64 `env:"OPENAI_COMPAT_PROMPT_PRICE" envDefault:"0"`
+	OpenAICompatCompletionPrice float64 `env:"OPENAI_COMPAT_COMPLETION_PRICE" envDefault:"0"`
+	OpenAICompatContextLength   int     `env:"OPENAI_COMPAT_CONTEXT_LENGTH" envDefault:"8192"`
+	OpenAICompatTools           bool    `env:"OPENAI_COMPAT_TOOLS" envDefault:"false"`
+	OpenAICompatReasoning       bool    `env:"OPENAI_COMPAT_REASONING" envDefault:"false"`
+
+	// Speech-to-text model for voice messages sent to models without audio input
+	STTModel string `env:"STT_MODEL" envDefault:"google/gemini-2.5-flash"`
+
+	// Cheap model that compresses long sessions into summaries
+	SummaryModel string `env:"SUMMARY_MODEL" envDefault:"google/gemini-2.5-flash-lite"`
+
+	// Text-to-speech: OpenAI-compatible /audio/speech endpoint for voice replies.
+	// Price is USD per 1M characters of synthesized text.
+	TTSEnabled bool    `env:"TTS_ENABLED" envDefault:"false"`
+	TTSBaseURL string  `env:"TTS_BASE_URL" envDefault:"https://api.openai.com/v1"`
+	TTSAPIKey  string  `env:"TTS_API_KEY"`
+	TTSModel   string  `env:"TTS_MODEL" envDefault:"gpt-4o-mini-tts"`
+	TTSVoice   string  `env:"TTS_VOICE" envDefault:"alloy"`
+	TTSPrice   float64 `env:"TTS_PRICE" envDefault:"15"`
+
+	// Tools: exchange rates API used by the currency tool
+	CurrencyAPIURL string `env:"CURRENCY_API_URL" envDefault:"https://open.er-api.com/v6/latest"`
 
 	// Payment: Telegram Stars
 	StarsEnabled bool `env:"STARS_ENABLED" envDefault:"true"`
@@ -40,13 +70,13 @@ type Config struct {
 	DropPendingUpdates bool `env:"BOT_DROP_PENDING_UPDATES" envDefault:"false"`
 
 	// Telegram logging
-	LogTelegramChatID      int64 `env:"LOG_TELEGRAM_CHAT_ID"`
-	LogTopicError          int   `env:"LOG_TOPIC_ERROR"`
-	LogTopicBalanceTopUp   int   `env:"LOG_TOPIC_BALANCE_TOPUP"`
-	LogTopicPromoActivate  int   `env:"LOG_TOPIC_PROMO_ACTIVATE"`
-	LogTopicPremiumPurchase int  `env:"LOG_TOPIC_PREMIUM_PURCHASE"`
-	LogTopicFreeBalance    int   `env:"LOG_TOPIC_FREE_BALANCE"`
-	LogTopicRegistration   int   `env:"LOG_TOPIC_REGISTRATION"`
+	LogTelegramChatID       int64 `env:"LOG_TELEGRAM_CHAT_ID"`
+	LogTopicError           int   `env:"LOG_TOPIC_ERROR"`
+	LogTopicBalanceTopUp    int   `env:"LOG_TOPIC_BALANCE_TOPUP"`
+	LogTopicPromoActivate   int   `env:"LOG_TOPIC_PROMO_ACTIVATE"`
+	LogTopicPremiumPurchase int   `env:"LOG_TOPIC_PREMIUM_PURCHASE"`
+	LogTopicFreeBalance     int   `env:"LOG_TOPIC_FREE_BALANCE"`
+	LogTopicRegistration    int   `env:"LOG_TOPIC_REGISTRATION"`
 }
 
 func Load() (*Config, error) {
@@ -26,8 +26,109 @@ const (
 	// AI request timeout
 	RequestTimeout = 90 * time.Second
 
-	// Model cache duration
-	ModelCacheDuration = 1 * time.Hour
+	// Context window: tokens kept free for the answer when trimming history
+	// (at most a quarter of the model's context length)
+	ContextCompletionReserve = 4096
+
+	// Summaries: messages kept verbatim when a full session is compressed, and
+	// the longest message passed to the summarizer
+	SummaryKeepMessages  = 20
+	SummaryMessageMaxLen = 4000
+
+	// Minimum interval between edits of a streamed answer
+	StreamEditInterval = 1500 * time.Millisecond
+
+	// Fallback models: how long to wait for the first token (or the whole answer
+	// without streaming) before switching, and how many free models to try by default
+	FallbackAttemptTimeout = 40 * time.Second
+	DefaultFreeFallbacks   = 2
+
+	// Upstream retries: attempts after the first one, and backoff bounds.
+	// A Retry-After longer than the max delay is not waited for.
+	UpstreamMaxRetries     = 2
+	UpstreamRetryBaseDelay = 500 * time.Millisecond
+	UpstreamRetryMaxDelay  = 8 * time.Second
+
+	// Voice messages: longest accepted recording and largest file (Bot API download limit)
+	MaxVoiceDuration = 10 * time.Minute
+	MaxVoiceFileSize = 20 << 20
+
+	// Documents: largest file downloaded (Bot API limit), the most characters
+	// of extracted text passed to the model and the most bytes inflated from
+	// the compressed parts of one file
+	MaxDocumentSize     = 20 << 20
+	MaxDocumentChars    = 100_000
+	MaxDocumentInflated = 8 * MaxDocumentSize
+
+	// Voice replies: longest text per synthesized voice message and the most
+	// voice messages sent for one answer
+	MaxTTSChunkLen = 4000
+	MaxTTSChunks   = 3
+
+	// Tool calling: model round-trips per request, per-call timeout and the
+	// longest tool result passed back to the model
+	MaxToolIterations = 5
+	ToolCallTimeout   = 15 * time.Second
+	ToolResultMaxLen  = 8000
+
+	// fetch_url tool: largest page downloaded
+	FetchURLMaxBytes = 2 << 20
+
+	// currency tool: how long exchange rates are reused
+	CurrencyRatesCacheDuration = time.Hour
+
+	// Circuit breaker: consecutive failures that disable a model, and for how long
+	CircuitFailureThreshold = 5
+	CircuitOpenDuration     = 60 * time.Second
+
+	// How often the model catalogue is fetched from the providers
+	ModelRefreshInterval = 1 * time.Hour
+
+	// /modelchanges: default period in days and the most rows shown per section
+	ModelChangesDefaultDays = 7
+	ModelChangesLimit       = 30
+
+	// Cost reconciliation: how often charges are checked against the provider's
+	// generation stats, how old a charge must be (the stats appear with a
+	// delay), how many are checked at once, how often a missing one is
+	// retried, and the smallest difference corrected on the balance
+	CostReconcileInterval    = 1 * time.Minute
+	CostReconcileDelay       = 1 * time.Minute
+	CostReconcileBatch       = 50
+	CostReconcileMaxAttempts = 10
+	CostCorrectionThreshold  = 0.000001
+
+	// /drift: default period in days and the most models listed
+	CostDriftDefaultDays = 7
+	CostDriftModelsLimit = 10
+
+	// Cost confirmation: how long a held request waits for an answer, and the
+	// shortest answer worth sending when it is cut to the user's cost limit
+	CostConfirmTimeout        = 10 * time.Minute
+	MinCappedCompletionTokens = 256
+
+	// /compare: how many models one comparison asks, and how long the user
+	// may pick the model to continue with
+	CompareMinModels     = 2
+	CompareMaxModels     = 4
+	CompareChoiceTimeout = 30 * time.Minute
+
+	// Inline mode: the pause after the last keystroke before a query is
+	// answered, the time the model has (Telegram drops answers after ~10s),
+	// the longest answer, and how long an identical query is answered from
+	// the cache instead of being charged again
+	InlineMinQueryLength = 3
+	InlineDebounce       = 1 * time.Second
+	InlineRequestTimeout = 8 * time.Second
+	InlineMaxTokens      = 1024
+	InlineCacheTTL       = 10 * time.Minute
+
+	// Model aliases: the most a user may define and the longest name
+	MaxModelAliases   = 20
+	MaxModelAliasName = 24
+
+	// Pause between notifications sent to many chats at once
+	NotifyInterval = 50 * time.Millisecond
 
 	// Telegram Stars conversion rate
 	XTRToDollarRate = 0.013
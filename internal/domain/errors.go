@@ -22,4 +22,11 @@ var (
 	ErrNotGroupAdmin       = errors.New("not a group admin")
 	ErrLowBalance          = errors.New("balance too low for this model")
 	ErrInvalidAmount       = errors.New("invalid amount")
+	ErrRateLimited         = errors.New("rate limited by AI provider")
+	ErrServiceUnavailable  = errors.New("AI provider unavailable")
+	ErrCircuitOpen         = errors.New("model temporarily disabled after repeated failures")
+	ErrUnsupportedDocument = errors.New("unsupported document type")
+	ErrEmptyDocument       = errors.New("no text found in document")
+	ErrAliasNotFound       = errors.New("model alias not found")
+	ErrAliasLimitReached   = errors.New("model alias limit reached")
 )
@@ -2,22 +2,47 @@ package domain
 
 type AIModel struct {
 	ID              string
+	Provider        string // name of the LLM provider serving the model
 	Name            string
 	Description     string
 	PromptPrice     float64 // per 1M tokens
 	CompletionPrice float64 // per 1M tokens
+	ImagePrice      float64 // per generated image, 0 if not priced separately
+	InputImagePrice float64 // per image sent to the model
+	RequestPrice    float64 // flat fee per request
+	CacheReadPrice  float64 // per 1M prompt tokens read from the prompt cache, 0 if not discounted
+	CacheWritePrice float64 // per 1M prompt tokens written to the prompt cache, 0 if not charged extra
 	ContextLength   int
+	MaxOutputTokens int // longest answer the model gives, 0 if unknown
 	UsageCount      int
 	Capabilities    ModelCapabilities
 }
 
 type ModelCapabilities struct {
-	Vision          bool
-	Audio           bool
-	ImageGeneration bool
-	Files           bool
+	Vision            bool // accepts images
+	Audio             bool // accepts audio
+	ImageGeneration   bool
+	Files             bool // accepts files such as PDFs
+	Tools             bool // supports function calling
+	Reasoning         bool // thinks before answering, with adjustable effort
+	Temperature       bool // accepts a sampling temperature
+	TopP              bool
+	MaxTokens         bool
+	Penalties         bool // frequency and presence penalties
+	Seed              bool
+	Stop              bool // stop sequences
+	JSONMode          bool // can be asked to answer with any JSON object
+	StructuredOutputs bool // can answer in a given JSON schema
+	PromptCaching     bool // caches the prompt only at cache_control breakpoints
+}
+
+// ModelAlias is a user's short name for a model, used as "@alias question".
+type ModelAlias struct {
+	Alias   string
+	ModelID string
 }
 
 func (m *AIModel) IsFree() bool {
-	return m.PromptPrice == 0 && m.CompletionPrice == 0
+	return m.PromptPrice == 0 && m.CompletionPrice == 0 && m.ImagePrice == 0 &&
+		m.InputImagePrice == 0 && m.RequestPrice == 0
 }
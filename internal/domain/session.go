@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -9,19 +10,49 @@ type ChatSession struct {
 	UserID      int64
 	Model       string
 	Temperature float64
+	Params      SessionParams
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+
+	// A branch of another session, started at one of its answers
+	ParentSessionID *int64
+	ForkMessageID   *int64
+}
+
+// SessionParams are optional generation parameters of a session. Unset
+// fields are left to the model's defaults.
+type SessionParams struct {
+	TopP             *float64        `json:"top_p,omitempty"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	Seed             *int64          `json:"seed,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	ResponseFormat   string          `json:"response_format,omitempty"` // "", "json_object" or "json_schema"
+	JSONSchema       json.RawMessage `json:"json_schema,omitempty"`     // schema for "json_schema"
 }
 
 type SessionMessage struct {
-	ID        int64
-	SessionID int64
-	Role      string
-	Text      string
-	Images    []string
-	IsSystem  bool
-	CreatedAt time.Time
-	Files     []MessageFile
+	ID         int64
+	SessionID  int64
+	Role       string
+	Text       string
+	Images     []string
+	IsSystem   bool
+	CreatedAt  time.Time
+	Files      []MessageFile
+	ToolCalls  []ToolCall // functions called by an assistant message
+	ToolCallID string     // call answered by a tool message
+	IsSummary  bool       // summary of archived older messages
+
+	TelegramMessageIDs []int64 // chat messages showing it, to find and edit them later
+}
+
+// ToolCall is a function call made by the model while answering.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string // JSON object
 }
 
 type MessageFile struct {
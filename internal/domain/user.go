@@ -28,6 +28,10 @@ type User struct {
 	SendUserInfo     bool
 	ContextEnabled   bool
 	SessionTimeoutMs int
+	VoiceReplies     bool
+	ShowReasoning    bool            // send the model's reasoning as a collapsed quote
+	ReasoningEffort  string          // low, medium, high; empty for the model default
+	MaxRequestCost   decimal.Decimal // most a single AI request may cost; zero for no limit
 
 	LastSkysmart time.Time
 	CreatedAt    time.Time
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/middleware"
+)
+
+const aliasUsage = "Отправьте «@псевдоним вопрос» или «!псевдоним вопрос», чтобы задать один вопрос другой модели. Выбранная модель и диалог не меняются.\n\n" +
+	"/alias <псевдоним> <модель> — добавить, например /alias sonnet anthropic/claude-sonnet-4\n" +
+	"/alias <псевдоним> - — удалить"
+
+// handleAlias manages the user's model aliases.
+//
+//	/alias                  — list the aliases
+//	/alias <name> <model>   — add or change one
+//	/alias <name> -         — remove it
+func (h *Handler) handleAlias(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil || update.Message.Chat.Type != "private" {
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+	reply := func(text string) {
+		b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text})
+	}
+
+	parts := strings.Fields(update.Message.Text)
+	if len(parts) == 1 {
+		h.sendAliases(ctx, b, chatID, user)
+		return
+	}
+	if len(parts) != 3 {
+		reply(aliasUsage)
+		return
+	}
+
+	name := normalizeAlias(parts[1])
+	if !validAlias(name) {
+		reply(fmt.Sprintf("❌ Псевдоним — до %d букв, цифр и знаков _ - . без пробелов.", config.MaxModelAliasName))
+		return
+	}
+
+	if parts[2] == "-" {
+		err := h.aliases.Delete(ctx, user.ID, name)
+		switch {
+		case errors.Is(err, domain.ErrAliasNotFound):
+			reply(fmt.Sprintf("❌ Псевдонима @%s нет.", name))
+		case err != nil:
+			slog.Error("delete model alias", "error", err)
+		default:
+			reply(fmt.Sprintf("🗑 Псевдоним @%s удалён.", name))
+		}
+		return
+	}
+
+	model, err := h.llm.GetModel(ctx, parts[2])
+	if err != nil {
+		reply("❌ Модель не найдена. Укажите ID модели из /models, например anthropic/claude-sonnet-4.")
+		return
+	}
+
+	err = h.aliases.Set(ctx, user.ID, name, model.ID)
+	if errors.Is(err, domain.ErrAliasLimitReached) {
+		reply(fmt.Sprintf("❌ Можно задать не больше %d псевдонимов. Удалите ненужные: /alias <псевдоним> -", config.MaxModelAliases))
+		return
+	}
+	if err != nil {
+		slog.Error("set model alias", "error", err)
+		return
+	}
+	reply(fmt.Sprintf("✅ @%s → %s\n\nПример: @%s объясни, как работает DNS", name, model.ID, name))
+}
+
+// sendAliases lists the user's aliases.
+func (h *Handler) sendAliases(ctx context.Context, b *bot.Bot, chatID int64, user *domain.User) {
+	aliases, err := h.aliases.List(ctx, user.ID)
+	if err != nil {
+		slog.Error("list model aliases", "error", err)
+		return
+	}
+
+	var sb strings.Builder
+	if len(aliases) == 0 {
+		sb.WriteString("🏷 У вас нет псевдонимов моделей.\n\n")
+	} else {
+		sb.WriteString("🏷 Псевдонимы моделей:\n\n")
+		for _, a := range aliases {
+			sb.WriteString(fmt.Sprintf("@%s → %s\n", a.Alias, a.ModelID))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(aliasUsage)
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   sb.String(),
+	})
+}
+
+// aliasedModel resolves a message starting with "@alias" or "!alias" to the
+// model of one of the user's aliases, and returns the rest of the message.
+// Anything else, including a mention that is not an alias, is left alone.
+func (h *Handler) aliasedModel(ctx context.Context, user *domain.User, text string) (modelID, rest string, ok bool) {
+	if !strings.HasPrefix(text, "@") && !strings.HasPrefix(text, "!") {
+		return "", "", false
+	}
+	name, rest := text[1:], ""
+	if i := strings.IndexFunc(name, unicode.IsSpace); i >= 0 {
+		name, rest = name[:i], strings.TrimSpace(name[i:])
+	}
+	name = normalizeAlias(name)
+	if !validAlias(name) {
+		return "", "", false
+	}
+
+	modelID, err := h.aliases.Resolve(ctx, user.ID, name)
+	if err != nil {
+		if !errors.Is(err, domain.ErrAliasNotFound) {
+			slog.Error("get model alias", "error", err)
+		}
+		return "", "", false
+	}
+	return modelID, rest, true
+}
+
+// normalizeAlias lowercases an alias and drops the @ or ! it may be typed with.
+func normalizeAlias(name string) string {
+	return strings.ToLower(strings.TrimLeft(name, "@!"))
+}
+
+func validAlias(name string) bool {
+	if name == "" || utf8.RuneCountInString(name) > config.MaxModelAliasName {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-.", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/set-night/mindapp/internal/config"
+)
+
+func TestValidAlias(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"sonnet", true},
+		{"gpt-4.1_mini", true},
+		{"клод", true},
+		{"4o", true},
+		{"", false},
+		{"two words", false},
+		{"a/b", false},
+		{"emoji😀", false},
+		{strings.Repeat("x", config.MaxModelAliasName), true},
+		{strings.Repeat("x", config.MaxModelAliasName+1), false},
+		{strings.Repeat("я", config.MaxModelAliasName), true},
+	}
+	for _, tt := range tests {
+		if got := validAlias(tt.name); got != tt.want {
+			t.Errorf("validAlias(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeAlias(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Sonnet", "sonnet"},
+		{"@GPT", "gpt"},
+		{"!Клод", "клод"},
+		{"@@x", "x"},
+	}
+	for _, tt := range tests {
+		if got := normalizeAlias(tt.name); got != tt.want {
+			t.Errorf("normalizeAlias(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
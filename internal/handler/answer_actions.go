@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/service"
+	tg "github.com/set-night/mindapp/internal/telegram"
+)
+
+// Actions on the last answer of a session, see privateRequest.Action.
+const (
+	actionRegenerate = "regenerate" // answer the last prompt again, replacing the answer
+	actionContinue   = "continue"   // append to an answer cut off by the length limit
+	actionEdit       = "edit"       // the last prompt was edited, answer it again
+)
+
+// continuePrompt asks the model to go on with its previous answer. It is only
+// sent, never stored: the continuation is appended to the stored answer.
+const continuePrompt = "Продолжи свой предыдущий ответ ровно с того места, где он оборвался. Не повторяй уже написанное и ничего не добавляй от себя."
+
+// cancelKeyboard is shown under the status message while a request runs.
+func cancelKeyboard() *models.InlineKeyboardMarkup {
+	return tg.InlineKeyboard(tg.ButtonRow(tg.InlineButton("✖️ Отменить", "answer_cancel")))
+}
+
+// answerKeyboard is shown under an answer; truncated adds the Continue button.
+func answerKeyboard(answerID int64, truncated bool) *models.InlineKeyboardMarkup {
+	row := tg.ButtonRow(tg.InlineButton("🔄 Перегенерировать", fmt.Sprintf("answer_regen_%d", answerID)))
+	if truncated {
+		row = append(row, tg.InlineButton("▶️ Продолжить", fmt.Sprintf("answer_continue_%d", answerID)))
+	}
+	return tg.InlineKeyboard(row)
+}
+
+// linkAnswer shows the answer buttons under the last of the chat messages an
+// answer was sent in, and remembers the messages so that they can be rewritten
+// when the prompt is edited.
+func (h *Handler) linkAnswer(ctx context.Context, b *bot.Bot, chatID int64, answer *domain.SessionMessage, messageIDs []int, truncated bool) {
+	b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:      chatID,
+		MessageID:   messageIDs[len(messageIDs)-1],
+		ReplyMarkup: answerKeyboard(answer.ID, truncated),
+	})
+
+	// A continuation adds to the messages of the answer it continues
+	ids := answer.TelegramMessageIDs
+	for _, id := range messageIDs {
+		ids = append(ids, int64(id))
+	}
+	if err := h.sessionService.SetTelegramMessageIDs(ctx, answer.ID, ids); err != nil {
+		slog.Error("save answer message ids", "error", err)
+	}
+	answer.TelegramMessageIDs = ids
+}
+
+// lastAnswer checks that answerID is the last message of the session and
+// returns the index of the prompt it answers. Tool calls and results between
+// the two belong to the answer.
+func lastAnswer(history []domain.SessionMessage, answerID int64) (int, bool) {
+	if len(history) == 0 {
+		return 0, false
+	}
+	last := history[len(history)-1]
+	if last.ID != answerID || last.Role != "assistant" {
+		return 0, false
+	}
+	for i := len(history) - 2; i >= 0; i-- {
+		if history[i].Role == "user" && !history[i].IsSystem && !history[i].IsSummary {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// handleAnswerAction regenerates or continues an answer from its buttons.
+func (h *Handler) handleAnswerAction(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	msg := update.CallbackQuery.Message.Message
+	if user == nil || msg == nil {
+		return
+	}
+
+	data := strings.TrimPrefix(update.CallbackQuery.Data, "answer_")
+	action := actionRegenerate
+	idStr, ok := strings.CutPrefix(data, "regen_")
+	if !ok {
+		action = actionContinue
+		idStr = strings.TrimPrefix(data, "continue_")
+	}
+	answerID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return
+	}
+
+	h.handlePrivateRequest(ctx, b, msg.Chat.ID, user, privateRequest{
+		Action:   action,
+		AnswerID: answerID,
+		// The buttons only work once; a new answer gets its own. A rejected
+		// request keeps them, so that it can be tried again
+		Accepted: func() {
+			b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+				ChatID:    msg.Chat.ID,
+				MessageID: msg.ID,
+			})
+		},
+	})
+}
+
+// replayFiles loads the recording or document attached to a prompt that is
+// answered again, for the parts of it the model gets as files: a recording
+// for a model that listens and a PDF without a text layer. It returns false
+// if the request cannot go on; the user has already been told why.
+func (h *Handler) replayFiles(ctx context.Context, b *bot.Bot, chatID int64, model *domain.AIModel, prompt domain.SessionMessage) (*privateAudio, *privateDocument, bool) {
+	files, err := h.sessionService.GetMessageFiles(ctx, prompt.ID)
+	if err != nil {
+		slog.Error("get prompt files", "error", err)
+		return nil, nil, false
+	}
+
+	var audio *privateAudio
+	var document *privateDocument
+	for _, f := range files {
+		switch f.FileType {
+		case "audio":
+			if !model.Capabilities.Audio {
+				continue
+			}
+			data, filePath, err := tg.DownloadFile(ctx, b, f.URL)
+			if err != nil {
+				slog.Error("download voice", "error", err)
+				b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Не удалось загрузить аудио."})
+				return nil, nil, false
+			}
+			audio = &privateAudio{Data: data, Format: service.AudioFormat(filePath), FileID: f.URL, Name: f.Name}
+		case "document":
+			if !strings.EqualFold(filepath.Ext(f.Name), ".pdf") {
+				continue // the text is in the prompt
+			}
+			data, _, err := tg.DownloadFile(ctx, b, f.URL)
+			if err != nil {
+				slog.Error("download document", "error", err)
+				b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: "❌ Не удалось загрузить файл."})
+				return nil, nil, false
+			}
+			doc, err := service.ExtractDocument(f.Name, "", data)
+			if err != nil || doc.PDF == nil {
+				continue
+			}
+			if model.Provider != service.ProviderOpenRouter {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: chatID,
+					Text:   "❌ В PDF нет текстового слоя (возможно, это скан). Отправьте страницы как фото или выберите модель OpenRouter.",
+				})
+				return nil, nil, false
+			}
+			document = &privateDocument{Document: doc, FileID: f.URL}
+		}
+	}
+	return audio, document, true
+}
+
+// handleCancelRequest cancels the chat's running request. Nothing is charged
+// and the answer is not saved.
+func (h *Handler) handleCancelRequest(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	msg := update.CallbackQuery.Message.Message
+	if msg == nil {
+		return
+	}
+
+	cancel, ok := h.inFlight.Take(msg.Chat.ID)
+	text := "Запрос уже завершён."
+	if ok {
+		cancel()
+		text = "Запрос отменён."
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: update.CallbackQuery.ID,
+		Text:            text,
+	})
+}
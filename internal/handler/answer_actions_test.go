@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+func TestLastAnswer(t *testing.T) {
+	history := []domain.SessionMessage{
+		{ID: 1, Role: "system", IsSystem: true},
+		{ID: 2, Role: "user"},
+		{ID: 3, Role: "assistant"},
+		{ID: 4, Role: "user"},
+		{ID: 5, Role: "assistant"}, // tool call
+		{ID: 6, Role: "tool"},
+		{ID: 7, Role: "assistant"},
+	}
+
+	tests := []struct {
+		name     string
+		history  []domain.SessionMessage
+		answerID int64
+		want     int
+		ok       bool
+	}{
+		{"last answer after tool calls", history, 7, 3, true},
+		{"older answer", history, 3, 0, false},
+		{"not an answer", history[:5], 4, 0, false},
+		{"answer to a summary only", []domain.SessionMessage{{ID: 1, Role: "user", IsSummary: true}, {ID: 2, Role: "assistant"}}, 2, 0, false},
+		{"empty", nil, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lastAnswer(tt.history, tt.answerID)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("lastAnswer() = %d, %v, want %d, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/service"
+)
+
+// handleCircuits shows models disabled by the circuit breaker.
+//
+//	/circuits               — list models with recent failures
+//	/circuits reset <model> — re-enable a model
+func (h *Handler) handleCircuits(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil || !user.IsAdmin {
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	parts := strings.Fields(update.Message.Text)
+
+	if len(parts) == 3 && parts[1] == "reset" {
+		h.llm.ResetCircuit(parts[2])
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      fmt.Sprintf("✅ `%s` снова доступна.", parts[2]),
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+		return
+	}
+
+	circuits := h.llm.Circuits()
+	if len(circuits) == 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "✅ Все модели работают без сбоев.",
+		})
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("⚡️ Состояние моделей:\n\n")
+	for _, c := range circuits {
+		switch c.State {
+		case service.CircuitOpen:
+			left := config.CircuitOpenDuration - time.Since(c.OpenedAt)
+			sb.WriteString(fmt.Sprintf("🔴 %s — отключена ещё %d сек.\n", c.Model, int(left.Seconds())+1))
+		case service.CircuitHalfOpen:
+			sb.WriteString(fmt.Sprintf("🟡 %s — пробный запрос\n", c.Model))
+		default:
+			sb.WriteString(fmt.Sprintf("🟢 %s — ошибок подряд: %d\n", c.Model, c.Failures))
+		}
+		sb.WriteString(fmt.Sprintf("   %s\n\n", c.LastError))
+	}
+	sb.WriteString("Сбросить: /circuits reset <модель>")
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   sb.String(),
+	})
+}
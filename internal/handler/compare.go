@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+	"github.com/set-night/mindapp/internal/service"
+	tg "github.com/set-night/mindapp/internal/telegram"
+	"github.com/shopspring/decimal"
+)
+
+// comparison is a finished /compare waiting for the user to pick the model
+// that continues the session.
+type comparison struct {
+	sessionID int64
+	prompt    string
+	models    []string // models that answered
+	answers   []string
+}
+
+// handleCompare sends one prompt to several models at once, with the current
+// session as context.
+//
+//	/compare <model1> <model2> [model3 model4] <prompt>
+//	/compare <prompt> — compare the favorite models
+func (h *Handler) handleCompare(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	msg := update.Message
+	chatID := msg.Chat.ID
+	if msg.Chat.Type != "private" {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "⚖️ Сравнение моделей доступно только в личном чате с ботом.",
+		})
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+
+	// Leading arguments that name models select them, the rest is the prompt
+	prompt := strings.TrimSpace(strings.TrimPrefix(msg.Text, "/compare"))
+	var chosen []*domain.AIModel
+	for prompt != "" {
+		end := strings.IndexFunc(prompt, unicode.IsSpace)
+		if end < 0 {
+			end = len(prompt)
+		}
+		model, err := h.llm.GetModel(ctx, prompt[:end])
+		if err != nil {
+			break
+		}
+		if !slices.ContainsFunc(chosen, func(m *domain.AIModel) bool { return m.ID == model.ID }) {
+			chosen = append(chosen, model)
+		}
+		prompt = strings.TrimSpace(prompt[end:])
+	}
+	if len(chosen) == 0 {
+		for _, id := range user.FavoriteModels {
+			if model, err := h.llm.GetModel(ctx, id); err == nil && len(chosen) < config.CompareMaxModels {
+				chosen = append(chosen, model)
+			}
+		}
+	}
+
+	if prompt == "" || len(chosen) < config.CompareMinModels || len(chosen) > config.CompareMaxModels {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text: fmt.Sprintf("⚖️ Использование: /compare <модель1> <модель2> [модель3 модель4] <запрос>\n\n"+
+				"Можно указать от %d до %d моделей. Без моделей сравниваются избранные (/favorite).",
+				config.CompareMinModels, config.CompareMaxModels),
+		})
+		return
+	}
+
+	// Each model must pass the checks of a private request; the cooldown is
+	// the longest of theirs
+	var cooldown time.Duration
+	for _, model := range chosen {
+		if text := balanceDenial(user, model); text != "" {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   fmt.Sprintf("%s\n\nМодель: %s", text, model.ID),
+			})
+			return
+		}
+		cooldown = max(cooldown, requestCooldown(user, model))
+	}
+	if since := time.Since(user.LastInteraction); since < cooldown {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("⏳ Подождите %d секунд.", int((cooldown-since).Seconds())+1),
+		})
+		return
+	}
+
+	_, err := h.queries.TrySetActiveRequest(ctx, chatID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "⏳ Дождитесь ответа на предыдущий запрос.",
+		})
+		return
+	}
+	defer h.queries.RemoveActiveRequest(ctx, chatID)
+
+	h.userService.UpdateLastInteraction(ctx, user.ID)
+
+	if h.sessionService.IsExpired(user) || !user.ContextEnabled {
+		h.sessionService.Reset(ctx, user)
+	}
+	session, err := h.sessionService.FindOrCreate(ctx, user)
+	if err != nil {
+		slog.Error("find or create session", "error", err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Ошибка при создании сессии.",
+		})
+		return
+	}
+	history, err := h.sessionService.GetMessages(ctx, session.ID)
+	if err != nil {
+		slog.Error("get session messages", "error", err)
+		return
+	}
+
+	markupPercent := h.cfg.MarkupPercentNormal
+	if user.IsPremium() {
+		markupPercent = h.cfg.MarkupPercentPremium
+	}
+
+	// Each model gets as much of the session as fits its context
+	temperature := session.Temperature
+	reqs := make([]service.ChatRequest, len(chosen))
+	estimates := make([]service.CostEstimate, len(chosen))
+	minCost := decimal.Zero
+	paid := 0
+	for i, model := range chosen {
+		kept := history
+		if budget := service.ContextBudget(model); budget > 0 {
+			kept, _ = service.FitHistory(history, budget-service.EstimateTokens(prompt))
+		}
+		messages := append(service.SessionChatMessages(kept, false), service.ChatMessage{
+			Role:    "user",
+			Content: prompt,
+		})
+		reqs[i] = service.ApplySessionParams(service.ChatRequest{
+			Model:       model.ID,
+			Messages:    messages,
+			Temperature: &temperature,
+		}, session.Params)
+		if !model.IsFree() {
+			estimates[i] = service.EstimateCost(model, messages, 0, markupPercent)
+			if session.Params.MaxTokens != nil && model.Capabilities.MaxTokens {
+				estimates[i] = estimates[i].LimitCompletion(*session.Params.MaxTokens)
+			}
+			minCost = minCost.Add(estimates[i].Min)
+			paid++
+		}
+	}
+	if minCost.IsPositive() && minCost.GreaterThan(user.Balance) {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("❌ Сравнение обойдётся минимум в $%.4f, а на балансе $%.4f. Пополните баланс: /pay", minCost.InexactFloat64(), user.Balance.InexactFloat64()),
+		})
+		return
+	}
+
+	// All answers together must be covered by the balance: what is left after
+	// the prompts is split equally between the paid models' answers
+	if paid > 0 {
+		share := user.Balance.Sub(minCost).Div(decimal.NewFromInt(int64(paid)))
+		for i, model := range chosen {
+			if model.IsFree() {
+				continue
+			}
+			tokens := estimates[i].TokensWithin(estimates[i].Min.Add(share))
+			if tokens >= estimates[i].MaxCompletionTokens {
+				continue
+			}
+			if tokens < config.MinCappedCompletionTokens || !model.Capabilities.MaxTokens {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: chatID,
+					Text:   fmt.Sprintf("❌ Баланса ($%.4f) не хватит на ответы всех моделей. Пополните баланс: /pay или сравните меньше моделей.", user.Balance.InexactFloat64()),
+				})
+				return
+			}
+			if reqs[i].MaxTokens == nil || tokens < *reqs[i].MaxTokens {
+				reqs[i].MaxTokens = &tokens
+			}
+		}
+	}
+
+	stopTyping := tg.StartTyping(ctx, b, chatID)
+	defer stopTyping()
+	statusMsg, _ := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("⚖️ Сравниваю %d модели...", len(chosen)),
+	})
+
+	reqCtx, cancel := context.WithTimeout(ctx, config.RequestTimeout)
+	defer cancel()
+	results := h.llm.Compare(reqCtx, reqs)
+
+	if statusMsg != nil {
+		b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: statusMsg.ID})
+	}
+
+	cmp := &comparison{sessionID: session.ID, prompt: prompt}
+	for i, res := range results {
+		model := chosen[i]
+		if res.Err != nil {
+			slog.Error("compare models", "model", model.ID, "error", res.Err)
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    chatID,
+				Text:      fmt.Sprintf("🤖 `%s`\n\n%s", model.ID, h.llmErrorText(reqCtx, res.Err)),
+				ParseMode: models.ParseModeMarkdownV1,
+			})
+			continue
+		}
+		if len(res.Response.Choices) == 0 || strings.TrimSpace(res.Response.Choices[0].Message.Content) == "" {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    chatID,
+				Text:      fmt.Sprintf("🤖 `%s`\n\n❌ AI не вернул ответ.", model.ID),
+				ParseMode: models.ParseModeMarkdownV1,
+			})
+			continue
+		}
+		answer := res.Response.Choices[0].Message.Content
+
+		// An answer that could not be paid for is neither shown nor offered
+		costText := "бесплатно"
+		if !model.IsFree() {
+			usage := res.Response.Usage
+			baseCost := service.AnswerCost(usage, model, 0, 0, 0)
+			cost, _, err := h.billingService.ProcessUserTransaction(ctx, user.ID, baseCost.InexactFloat64(), markupPercent, fmt.Sprintf("Compare: %s", model.ID))
+			if err != nil {
+				slog.Error("charge for comparison", "model", model.ID, "error", err)
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:    chatID,
+					Text:      fmt.Sprintf("🤖 `%s`\n\n❌ Недостаточно средств, ответ не показан. Пополните баланс: /pay", model.ID),
+					ParseMode: models.ParseModeMarkdownV1,
+				})
+				continue
+			}
+			costText = fmt.Sprintf("$%.6f", cost.InexactFloat64())
+			h.trackCharge(ctx, service.GenerationCharge{
+				UserID:        &user.ID,
+				Model:         model,
+				Usage:         usage,
+				Charged:       cost,
+				MarkupPercent: markupPercent,
+			})
+		}
+
+		header := fmt.Sprintf("🤖 `%s` · ⏱ %.1f с · 💰 %s", model.ID, res.Latency.Seconds(), costText)
+		if err := tg.SendLongMessage(ctx, b, chatID, header+"\n\n"+answer, nil); err != nil {
+			slog.Error("send comparison answer", "error", err)
+		}
+		cmp.models = append(cmp.models, model.ID)
+		cmp.answers = append(cmp.answers, answer)
+	}
+
+	if len(cmp.models) == 0 {
+		return
+	}
+	// The buttons carry the comparison's id: compare_pick_<id>_<answer>
+	id := h.comparisons.Put(chatID, cmp)
+
+	var rows [][]models.InlineKeyboardButton
+	for i, modelID := range cmp.models {
+		rows = append(rows, tg.ButtonRow(
+			tg.InlineButton("🏆 "+modelID, fmt.Sprintf("compare_pick_%d_%d", id, i)),
+		))
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        "Какой ответ лучше? Диалог продолжится с выбранной моделью.",
+		ReplyMarkup: tg.InlineKeyboard(rows...),
+	})
+}
+
+// handleComparePick continues the session with the chosen model: it becomes
+// the selected model and its answer is added to the session.
+func (h *Handler) handleComparePick(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	msg := update.CallbackQuery.Message.Message
+	if user == nil || msg == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+
+	idStr, idxStr, _ := strings.Cut(strings.TrimPrefix(update.CallbackQuery.Data, "compare_pick_"), "_")
+	id, err1 := strconv.ParseInt(idStr, 10, 64)
+	idx, err2 := strconv.Atoi(idxStr)
+	var cmp *comparison
+	ok := err1 == nil && err2 == nil
+	if ok {
+		cmp, ok = h.comparisons.TakeID(chatID, id)
+	}
+	if !ok || idx < 0 || idx >= len(cmp.models) {
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: msg.ID,
+			Text:      "⌛️ Сравнение устарело.",
+		})
+		return
+	}
+	modelID := cmp.models[idx]
+
+	h.queries.SetUserSelectedModel(ctx, sqlc.SetUserSelectedModelParams{
+		ID:            user.ID,
+		SelectedModel: modelID,
+	})
+	h.queries.UpdateSessionModel(ctx, sqlc.UpdateSessionModelParams{
+		ID:    cmp.sessionID,
+		Model: modelID,
+	})
+	if _, err := h.sessionService.AddMessage(ctx, cmp.sessionID, "user", cmp.prompt, nil, false); err != nil {
+		slog.Error("save compare prompt", "error", err)
+	}
+	if _, err := h.sessionService.AddMessage(ctx, cmp.sessionID, "assistant", cmp.answers[idx], nil, false); err != nil {
+		slog.Error("save compare answer", "error", err)
+	}
+
+	b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: msg.ID,
+		Text:      fmt.Sprintf("🏆 Диалог продолжается с `%s`.", modelID),
+		ParseMode: models.ParseModeMarkdownV1,
+	})
+}
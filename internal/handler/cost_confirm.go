@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/service"
+	tg "github.com/set-night/mindapp/internal/telegram"
+	"github.com/shopspring/decimal"
+)
+
+// How the user confirmed a request that may cost more than their limit.
+const (
+	costModeCapped = "capped" // send with the answer shortened to fit the limit
+	costModeFull   = "full"   // send without the limit this once, with the answer the balance covers
+)
+
+// askCostConfirmation holds a request that may cost more than the user's limit
+// and asks how to send it. tokens is the answer length that fits the limit.
+// The buttons carry the id of the held request: cost_confirm_<mode>_<id>.
+func (h *Handler) askCostConfirmation(ctx context.Context, b *bot.Bot, chatID int64, user *domain.User, req privateRequest, estimate service.CostEstimate, tokens int) {
+	id := h.pendingRequests.Put(chatID, req)
+	button := func(mode string) string {
+		return fmt.Sprintf("cost_confirm_%s_%d", mode, id)
+	}
+
+	text := fmt.Sprintf(
+		"💸 Запрос может стоить больше вашего лимита ($%s).\n\n"+
+			"Оценка: от $%.4f до $%.4f (~%d токенов запроса, ответ до %d токенов).",
+		user.MaxRequestCost.String(),
+		estimate.Min.InexactFloat64(),
+		estimate.Max.InexactFloat64(),
+		estimate.PromptTokens,
+		estimate.MaxCompletionTokens,
+	)
+
+	var rows [][]models.InlineKeyboardButton
+	if tokens >= config.MinCappedCompletionTokens {
+		rows = append(rows, tg.ButtonRow(
+			tg.InlineButton(fmt.Sprintf("✂️ Ответ до %d токенов", tokens), button(costModeCapped)),
+		))
+	} else {
+		text += "\n\nЗапрос целиком не укладывается в лимит."
+	}
+	rows = append(rows, tg.ButtonRow(
+		tg.InlineButton(fmt.Sprintf("✅ Без лимита (до $%.4f)", decimal.Min(estimate.Max, user.Balance).InexactFloat64()), button(costModeFull)),
+	))
+	rows = append(rows, tg.ButtonRow(
+		tg.InlineButton("❌ Отмена", button("cancel")),
+	))
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: tg.InlineKeyboard(rows...),
+	})
+}
+
+func (h *Handler) handleCostConfirm(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	msg := update.CallbackQuery.Message.Message
+	if user == nil || msg == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+
+	mode, idStr, _ := strings.Cut(strings.TrimPrefix(update.CallbackQuery.Data, "cost_confirm_"), "_")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return
+	}
+	req, ok := h.pendingRequests.TakeID(chatID, id)
+
+	status := "❌ Запрос отменён."
+	switch {
+	case !ok:
+		status = "⌛️ Запрос устарел, отправьте его ещё раз."
+	case mode == costModeCapped:
+		status = "✂️ Отправлено с ограничением ответа."
+	case mode == costModeFull:
+		status = "✅ Отправлено без лимита."
+	}
+	b.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:    chatID,
+		MessageID: msg.ID,
+		Text:      msg.Text + "\n\n" + status,
+	})
+
+	if !ok || (mode != costModeCapped && mode != costModeFull) {
+		return
+	}
+	req.CostMode = mode
+	h.handlePrivateRequest(ctx, b, chatID, user, req)
+}
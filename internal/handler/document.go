@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/service"
+	tg "github.com/set-night/mindapp/internal/telegram"
+)
+
+// privateDocument is a file whose text is added to the prompt.
+type privateDocument struct {
+	*service.Document
+	FileID string
+}
+
+// loadDocument downloads a document and extracts its text. It returns false
+// if the file cannot be used; the user has already been told why.
+func (h *Handler) loadDocument(ctx context.Context, b *bot.Bot, chatID int64, file *models.Document) (*privateDocument, bool) {
+	reply := func(text string) {
+		b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text})
+	}
+
+	if file.FileSize > config.MaxDocumentSize {
+		reply(fmt.Sprintf("❌ Файл слишком большой. Максимум — %d МБ.", config.MaxDocumentSize>>20))
+		return nil, false
+	}
+
+	data, _, err := tg.DownloadFile(ctx, b, file.FileID)
+	if err != nil {
+		slog.Error("download document", "error", err)
+		reply("❌ Не удалось загрузить файл.")
+		return nil, false
+	}
+
+	name := file.FileName
+	if name == "" {
+		name = "file"
+	}
+
+	doc, err := service.ExtractDocument(name, file.MimeType, data)
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedDocument):
+		reply("❌ Этот тип файлов не поддерживается. Можно отправить PDF, DOCX, текстовые файлы и исходный код.")
+		return nil, false
+	case errors.Is(err, domain.ErrEmptyDocument):
+		reply("❌ В файле не найден текст.")
+		return nil, false
+	case err != nil:
+		slog.Error("extract document", "error", err, "name", name)
+		reply("❌ Не удалось прочитать файл.")
+		return nil, false
+	}
+
+	if doc.Truncated {
+		reply(fmt.Sprintf("⚠️ Файл слишком длинный, модель получит только первые %d символов.", config.MaxDocumentChars))
+	}
+	return &privateDocument{Document: doc, FileID: file.FileID}, true
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+	"github.com/set-night/mindapp/internal/service"
+)
+
+// trackCharge records a charge for an AI answer, so that it is checked
+// against the provider's cost later.
+func (h *Handler) trackCharge(ctx context.Context, charge service.GenerationCharge) {
+	if err := h.reconciler.Track(ctx, charge); err != nil {
+		slog.Error("track charge", "error", err)
+	}
+}
+
+// handleDrift shows how far the charged estimates were from the costs the
+// provider reported, and what was corrected on the balances.
+//
+//	/drift        — the last week
+//	/drift <days> — the last <days> days
+func (h *Handler) handleDrift(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil || !user.IsAdmin {
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	days := config.CostDriftDefaultDays
+	if parts := strings.Fields(update.Message.Text); len(parts) > 1 {
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n <= 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "Использование: /drift [дней]",
+			})
+			return
+		}
+		days = n
+	}
+	since := pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, -days), Valid: true}
+
+	drift, err := h.queries.GetGenerationCostDrift(ctx, since)
+	if err != nil {
+		slog.Error("get cost drift", "error", err)
+		return
+	}
+	byModel, err := h.queries.GetGenerationCostDriftByModel(ctx, sqlc.GetGenerationCostDriftByModelParams{
+		CreatedAt: since,
+		Limit:     config.CostDriftModelsLimit,
+	})
+	if err != nil {
+		slog.Error("get cost drift by model", "error", err)
+		return
+	}
+
+	diff := drift.Actual.Sub(drift.Estimated)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚖️ Сверка стоимости за %d дн. (без наценки)\n\n", days))
+	sb.WriteString(fmt.Sprintf("Сверено ответов: %d, с поправкой: %d\n", drift.Reconciled, drift.Corrected))
+	sb.WriteString(fmt.Sprintf("Ожидают сверки: %d, не удалось сверить: %d\n\n", drift.Pending, drift.Failed))
+	sb.WriteString(fmt.Sprintf("Оценка: $%.6f\n", drift.Estimated.InexactFloat64()))
+	sb.WriteString(fmt.Sprintf("Факт: $%.6f\n", drift.Actual.InexactFloat64()))
+	sb.WriteString(fmt.Sprintf("Расхождение: %+.6f$", diff.InexactFloat64()))
+	if drift.Estimated.IsPositive() {
+		sb.WriteString(fmt.Sprintf(" (%+.2f%%)", diff.Div(drift.Estimated).InexactFloat64()*100))
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(fmt.Sprintf("Поправки с наценкой: списано $%.6f, возвращено $%.6f\n",
+		drift.ChargedExtra.InexactFloat64(), drift.Refunded.InexactFloat64()))
+
+	if len(byModel) > 0 {
+		sb.WriteString("\nПо моделям:\n")
+		for _, m := range byModel {
+			sb.WriteString(fmt.Sprintf("• %s — %+.6f$ (%d)\n", m.Model, m.Drift.InexactFloat64(), m.Generations))
+		}
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   sb.String(),
+	})
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/middleware"
+)
+
+// handleFallback lets admins view and edit per-model fallback chains.
+//
+//	/fallback                      — list configured chains
+//	/fallback <model>              — show the chain used for a model
+//	/fallback <model> <m1> [m2...] — set the chain
+//	/fallback <model> -            — remove the chain (free models use the default)
+func (h *Handler) handleFallback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil || !user.IsAdmin {
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	parts := strings.Fields(update.Message.Text)
+
+	if len(parts) == 1 {
+		chains, err := h.fallbackService.List(ctx)
+		if err != nil {
+			slog.Error("list fallbacks", "error", err)
+			return
+		}
+		if len(chains) == 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "Цепочки не настроены. Бесплатные модели используют цепочку по умолчанию.\n\nИспользование: /fallback <модель> <резерв1> [резерв2 ...]",
+			})
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("↪️ *Резервные модели:*\n\n")
+		for _, c := range chains {
+			sb.WriteString(fmt.Sprintf("`%s`\n→ `%s`\n\n", c.ModelID, strings.Join(c.FallbackModels, "` → `")))
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      sb.String(),
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+		return
+	}
+
+	modelID := parts[1]
+	model, err := h.llm.GetModel(ctx, modelID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Модель не найдена.",
+		})
+		return
+	}
+
+	switch {
+	case len(parts) == 2:
+		chain, err := h.fallbackService.Chain(ctx, model)
+		if err != nil {
+			slog.Error("get fallback chain", "error", err)
+			return
+		}
+		text := fmt.Sprintf("Для `%s` резервных моделей нет.", modelID)
+		if len(chain) > 0 {
+			text = fmt.Sprintf("`%s`\n→ `%s`", modelID, strings.Join(chain, "` → `"))
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      text,
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+
+	case len(parts) == 3 && parts[2] == "-":
+		if err := h.fallbackService.Clear(ctx, modelID); err != nil {
+			slog.Error("clear fallbacks", "error", err)
+			return
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "✅ Цепочка удалена.",
+		})
+
+	default:
+		chain := parts[2:]
+		for _, id := range chain {
+			if _, err := h.llm.GetModel(ctx, id); err != nil {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID:    chatID,
+					Text:      fmt.Sprintf("❌ Модель `%s` не найдена.", id),
+					ParseMode: models.ParseModeMarkdownV1,
+				})
+				return
+			}
+		}
+		if err := h.fallbackService.Set(ctx, modelID, chain); err != nil {
+			slog.Error("set fallbacks", "error", err)
+			return
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      fmt.Sprintf("✅ `%s`\n→ `%s`", modelID, strings.Join(chain, "` → `")),
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+	}
+}
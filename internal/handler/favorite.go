@@ -34,7 +34,7 @@ func (h *Handler) handleFavorite(ctx context.Context, b *bot.Bot, update *models
 
 	var rows [][]models.InlineKeyboardButton
 	for _, modelID := range user.FavoriteModels {
-		model, err := h.openRouter.GetModel(ctx, modelID)
+		model, err := h.llm.GetModel(ctx, modelID)
 		name := modelID
 		if err == nil {
 			name = model.Name
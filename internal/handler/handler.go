@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+
 	"github.com/go-telegram/bot"
 	"github.com/set-night/mindapp/internal/config"
 	"github.com/set-night/mindapp/internal/repository/sqlc"
@@ -16,14 +18,26 @@ type Handler struct {
 	groupService    *service.GroupService
 	sessionService  *service.SessionService
 	billingService  *service.BillingService
+	fallbackService *service.FallbackService
+	replacements    *service.ModelReplacementService
+	toolRegistry    *service.ToolRegistry
+	transcription   *service.TranscriptionService
+	tts             *service.TTSService
+	summary         *service.SummaryService
 	paymentService  *service.PaymentService
 	promoService    *service.PromoService
 	premiumService  *service.PremiumService
-	openRouter      *service.OpenRouterService
+	aliases         *service.ModelAliasService
+	reconciler      *service.CostReconciler
+	llm             *service.LLMRouter
 	skysmartService *service.SkysmartService
 	queries         *sqlc.Queries
 	tgLogger        *telegram.TelegramLogger
-	botUsername      string
+	botUsername     string
+	pendingRequests *pendingStore[privateRequest]
+	comparisons     *pendingStore[*comparison]
+	inFlight        *pendingStore[context.CancelFunc] // running private requests
+	inline          *inlineQueries
 }
 
 // Deps contains all dependencies required to construct a Handler.
@@ -34,14 +48,22 @@ type Deps struct {
 	GroupService    *service.GroupService
 	SessionService  *service.SessionService
 	BillingService  *service.BillingService
+	FallbackService *service.FallbackService
+	Replacements    *service.ModelReplacementService
+	ToolRegistry    *service.ToolRegistry
+	Transcription   *service.TranscriptionService
+	TTS             *service.TTSService
+	Summary         *service.SummaryService
 	PaymentService  *service.PaymentService
 	PromoService    *service.PromoService
 	PremiumService  *service.PremiumService
-	OpenRouter      *service.OpenRouterService
+	Aliases         *service.ModelAliasService
+	Reconciler      *service.CostReconciler
+	LLM             *service.LLMRouter
 	SkysmartService *service.SkysmartService
 	Queries         *sqlc.Queries
 	TgLogger        *telegram.TelegramLogger
-	BotUsername      string
+	BotUsername     string
 }
 
 // New creates a new Handler from the provided dependencies.
@@ -53,13 +75,25 @@ func New(deps Deps) *Handler {
 		groupService:    deps.GroupService,
 		sessionService:  deps.SessionService,
 		billingService:  deps.BillingService,
+		fallbackService: deps.FallbackService,
+		replacements:    deps.Replacements,
+		toolRegistry:    deps.ToolRegistry,
+		transcription:   deps.Transcription,
+		tts:             deps.TTS,
+		summary:         deps.Summary,
 		paymentService:  deps.PaymentService,
 		promoService:    deps.PromoService,
 		premiumService:  deps.PremiumService,
-		openRouter:      deps.OpenRouter,
+		aliases:         deps.Aliases,
+		reconciler:      deps.Reconciler,
+		llm:             deps.LLM,
 		skysmartService: deps.SkysmartService,
 		queries:         deps.Queries,
 		tgLogger:        deps.TgLogger,
-		botUsername:      deps.BotUsername,
+		botUsername:     deps.BotUsername,
+		pendingRequests: newPendingStore[privateRequest](config.CostConfirmTimeout),
+		comparisons:     newPendingStore[*comparison](config.CompareChoiceTimeout),
+		inFlight:        newPendingStore[context.CancelFunc](config.RequestTimeout),
+		inline:          newInlineQueries(),
 	}
 }
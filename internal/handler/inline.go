@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/service"
+	tg "github.com/set-night/mindapp/internal/telegram"
+)
+
+// inlineQueries tracks the inline query each user is typing and caches the
+// answers, so that only the finished query is charged and only once.
+type inlineQueries struct {
+	mu      sync.Mutex
+	seq     uint64
+	running map[int64]inlineRun // by user
+	answers map[string]inlineAnswer
+}
+
+type inlineRun struct {
+	seq    uint64
+	cancel context.CancelFunc
+}
+
+type inlineAnswer struct {
+	text    string
+	expires time.Time
+}
+
+func newInlineQueries() *inlineQueries {
+	return &inlineQueries{
+		running: make(map[int64]inlineRun),
+		answers: make(map[string]inlineAnswer),
+	}
+}
+
+// start begins answering a query of the user and cancels the previous one,
+// which the user has typed over. done must be called when it is answered.
+func (q *inlineQueries) start(ctx context.Context, userID int64) (runCtx context.Context, done func()) {
+	runCtx, cancel := context.WithCancel(ctx)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if prev, ok := q.running[userID]; ok {
+		prev.cancel()
+	}
+	q.seq++
+	seq := q.seq
+	q.running[userID] = inlineRun{seq: seq, cancel: cancel}
+
+	return runCtx, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if q.running[userID].seq == seq {
+			delete(q.running, userID)
+		}
+		cancel()
+	}
+}
+
+func (q *inlineQueries) cached(key string) (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	a, ok := q.answers[key]
+	if !ok || time.Now().After(a.expires) {
+		return "", false
+	}
+	return a.text, true
+}
+
+func (q *inlineQueries) store(key, text string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for k, a := range q.answers {
+		if now.After(a.expires) {
+			delete(q.answers, k)
+		}
+	}
+	q.answers[key] = inlineAnswer{text: text, expires: now.Add(config.InlineCacheTTL)}
+}
+
+// HandleInlineQuery answers "@bot question" in any chat with the user's
+// selected model, charged to their balance. The query is only sent once the
+// user stops typing.
+func (h *Handler) HandleInlineQuery(ctx context.Context, b *bot.Bot, update *models.Update) {
+	q := update.InlineQuery
+	if q == nil {
+		return
+	}
+	user := middleware.GetUser(ctx)
+	query := strings.TrimSpace(q.Query)
+	if user == nil || utf8.RuneCountInString(query) < config.InlineMinQueryLength {
+		return
+	}
+
+	key := fmt.Sprintf("%d:%s:%s", user.ID, user.SelectedModel, query)
+	if answer, ok := h.inline.cached(key); ok {
+		h.answerInline(ctx, b, q.ID, query, answer)
+		return
+	}
+
+	runCtx, done := h.inline.start(ctx, user.ID)
+	defer done()
+	select {
+	case <-time.After(config.InlineDebounce):
+	case <-runCtx.Done():
+		return
+	}
+
+	model, err := h.llm.GetModel(runCtx, user.SelectedModel)
+	if err != nil {
+		h.answerInlineError(ctx, b, q.ID, "❌ Модель не найдена", "Выберите модель в личном чате с ботом: /models")
+		return
+	}
+
+	// Inline answers share the cooldown of the private chat
+	cooldown := requestCooldown(user, model)
+	if since := time.Since(user.LastInteraction); since < cooldown {
+		remaining := cooldown - since
+		h.answerInlineError(ctx, b, q.ID, fmt.Sprintf("⏳ Подождите %d секунд", int(remaining.Seconds())+1), "Запросы к модели можно отправлять не чаще, чем в личном чате")
+		return
+	}
+	h.userService.UpdateLastInteraction(ctx, user.ID)
+
+	markupPercent := h.cfg.MarkupPercentNormal
+	if user.IsPremium() {
+		markupPercent = h.cfg.MarkupPercentPremium
+	}
+
+	// No one can confirm an expensive inline answer, so it is cut to the
+	// user's cost limit instead
+	messages := []service.ChatMessage{{Role: "user", Content: query}}
+	maxTokens := config.InlineMaxTokens
+	if !model.IsFree() {
+		estimate := service.EstimateCost(model, messages, 0, markupPercent)
+		if !user.Balance.IsPositive() || estimate.Min.GreaterThan(user.Balance) {
+			h.answerInlineError(ctx, b, q.ID, "❌ Недостаточно средств", "Пополните баланс в личном чате с ботом: /pay")
+			return
+		}
+		if user.MaxRequestCost.IsPositive() && estimate.LimitCompletion(maxTokens).Max.GreaterThan(user.MaxRequestCost) {
+			if !model.Capabilities.MaxTokens {
+				h.answerInlineError(ctx, b, q.ID, "❌ Запрос не укладывается в лимит стоимости", "Длину ответа этой модели ограничить нельзя")
+				return
+			}
+			maxTokens = min(maxTokens, estimate.TokensWithin(user.MaxRequestCost))
+			if maxTokens < config.MinCappedCompletionTokens {
+				h.answerInlineError(ctx, b, q.ID, "❌ Запрос не укладывается в лимит стоимости", "Лимит на запрос меняется в /settings")
+				return
+			}
+		}
+	}
+
+	temperature := user.Temperature
+	reqCtx, cancel := context.WithTimeout(runCtx, config.InlineRequestTimeout)
+	defer cancel()
+	resp, usedModel, err := h.fallbackService.Chat(reqCtx, service.ChatRequest{
+		Model:       model.ID,
+		Messages:    messages,
+		Temperature: &temperature,
+		MaxTokens:   &maxTokens,
+	})
+	if runCtx.Err() != nil {
+		// Typed over: the newer query is answered instead
+		return
+	}
+	if err != nil {
+		slog.Error("inline llm chat", "error", err)
+		h.answerInlineError(ctx, b, q.ID, h.llmErrorText(reqCtx, err), "Попробуйте короче или в личном чате с ботом")
+		return
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		h.answerInlineError(ctx, b, q.ID, "❌ AI не вернул ответ.", "Попробуйте переформулировать запрос")
+		return
+	}
+	answer := resp.Choices[0].Message.Content
+
+	if !usedModel.IsFree() {
+		usage := resp.Usage
+		baseCost := service.AnswerCost(usage, usedModel, len(resp.ImageURLs()), 0, 0)
+		cost, _, err := h.billingService.ProcessUserTransaction(ctx, user.ID, baseCost.InexactFloat64(), markupPercent, fmt.Sprintf("Inline: %s", usedModel.ID))
+		if err != nil {
+			slog.Error("charge for inline answer", "error", err)
+			h.answerInlineError(ctx, b, q.ID, "❌ Недостаточно средств", "Пополните баланс в личном чате с ботом: /pay")
+			return
+		}
+		h.trackCharge(ctx, service.GenerationCharge{
+			UserID:        &user.ID,
+			Model:         usedModel,
+			Usage:         usage,
+			Charged:       cost,
+			MarkupPercent: markupPercent,
+		})
+	}
+
+	h.inline.store(key, answer)
+	h.answerInline(ctx, b, q.ID, query, answer)
+}
+
+// answerInline offers the answer as a message with the question quoted.
+func (h *Handler) answerInline(ctx context.Context, b *bot.Bot, queryID, query, answer string) {
+	text := fmt.Sprintf("❓ %s\n\n%s", query, answer)
+	if runes := []rune(text); len(runes) > tg.MaxMessageLen {
+		text = string(runes[:tg.MaxMessageLen-1]) + "…"
+	}
+	h.sendInlineResults(ctx, b, queryID, int(config.InlineCacheTTL.Seconds()), &models.InlineQueryResultArticle{
+		ID:                  "answer",
+		Title:               "🤖 Отправить ответ",
+		Description:         snippet(answer, 100),
+		InputMessageContent: &models.InputTextMessageContent{MessageText: text},
+	})
+}
+
+// answerInlineError shows why there is no answer and what to do. It is
+// hardly cached, so the query can be retried at once.
+func (h *Handler) answerInlineError(ctx context.Context, b *bot.Bot, queryID, title, description string) {
+	h.sendInlineResults(ctx, b, queryID, 1, &models.InlineQueryResultArticle{
+		ID:                  "error",
+		Title:               title,
+		Description:         description,
+		InputMessageContent: &models.InputTextMessageContent{MessageText: title + "\n" + description},
+	})
+}
+
+// sendInlineResults answers an inline query. Telegram keeps the results for
+// cacheTime seconds; zero would mean its default of five minutes.
+func (h *Handler) sendInlineResults(ctx context.Context, b *bot.Bot, queryID string, cacheTime int, results ...models.InlineQueryResult) {
+	if _, err := b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: queryID,
+		Results:       results,
+		CacheTime:     cacheTime,
+		IsPersonal:    true,
+	}); err != nil {
+		slog.Error("answer inline query", "error", err)
+	}
+}
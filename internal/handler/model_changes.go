@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+)
+
+// handleModelChanges shows models whose price changed or that providers no
+// longer list.
+//
+//	/modelchanges        — changes of the last week
+//	/modelchanges <days> — changes of the last <days> days
+func (h *Handler) handleModelChanges(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil || !user.IsAdmin {
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	days := config.ModelChangesDefaultDays
+	if parts := strings.Fields(update.Message.Text); len(parts) > 1 {
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n <= 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "Использование: /modelchanges [дней]",
+			})
+			return
+		}
+		days = n
+	}
+	since := pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, -days), Valid: true}
+
+	changes, err := h.queries.GetRecentPriceChanges(ctx, sqlc.GetRecentPriceChangesParams{
+		ChangedAt: since,
+		Limit:     config.ModelChangesLimit,
+	})
+	if err != nil {
+		slog.Error("get price changes", "error", err)
+		return
+	}
+	removed, err := h.queries.GetRemovedAIModels(ctx, sqlc.GetRemovedAIModelsParams{
+		RemovedAt: since,
+		Limit:     config.ModelChangesLimit,
+	})
+	if err != nil {
+		slog.Error("get removed models", "error", err)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 Изменения моделей за %d дн.\n\n", days))
+
+	sb.WriteString("💲 Цены (за 1M токенов):\n")
+	shown := 0
+	for _, c := range changes {
+		var diffs []string
+		if c.PromptPrice != c.OldPromptPrice {
+			diffs = append(diffs, fmt.Sprintf("вход $%.2f → $%.2f", c.OldPromptPrice, c.PromptPrice))
+		}
+		if c.CompletionPrice != c.OldCompletionPrice {
+			diffs = append(diffs, fmt.Sprintf("выход $%.2f → $%.2f", c.OldCompletionPrice, c.CompletionPrice))
+		}
+		if c.ImagePrice != c.OldImagePrice {
+			diffs = append(diffs, fmt.Sprintf("изображение $%g → $%g", c.OldImagePrice, c.ImagePrice))
+		}
+		if c.RequestPrice != c.OldRequestPrice {
+			diffs = append(diffs, fmt.Sprintf("запрос $%g → $%g", c.OldRequestPrice, c.RequestPrice))
+		}
+		// A model that came back after removal gets a row with unchanged prices
+		if len(diffs) == 0 {
+			continue
+		}
+		shown++
+		sb.WriteString(fmt.Sprintf("• %s (%s)\n   %s\n", c.ModelID, c.ChangedAt.Time.Format("02.01 15:04"), strings.Join(diffs, ", ")))
+	}
+	if shown == 0 {
+		sb.WriteString("нет\n")
+	}
+
+	sb.WriteString("\n🗑 Исчезли:\n")
+	for _, m := range removed {
+		sb.WriteString(fmt.Sprintf("• %s (%s)\n", m.ID, m.RemovedAt.Time.Format("02.01 15:04")))
+	}
+	if len(removed) == 0 {
+		sb.WriteString("нет\n")
+	}
+
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   sb.String(),
+	})
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/middleware"
+)
+
+// handleReplace lets admins choose what replaces a model once it leaves the
+// catalogue.
+//
+//	/replace                         — list configured replacements
+//	/replace <model> <replacement>   — set the replacement
+//	/replace <model> -               — remove it (the default model is used)
+func (h *Handler) handleReplace(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil || !user.IsAdmin {
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	parts := strings.Fields(update.Message.Text)
+
+	switch {
+	case len(parts) == 1:
+		replacements, err := h.replacements.List(ctx)
+		if err != nil {
+			slog.Error("list replacements", "error", err)
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("🔁 *Замены исчезнувших моделей:*\n\n")
+		for _, r := range replacements {
+			sb.WriteString(fmt.Sprintf("`%s`\n→ `%s`\n\n", r.ModelID, r.Replacement))
+		}
+		sb.WriteString(fmt.Sprintf("Остальные заменяются на `%s`.\n\nИспользование: /replace <модель> <замена>", config.DefaultModel))
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      sb.String(),
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+
+	case len(parts) == 3 && parts[2] == "-":
+		if err := h.replacements.Clear(ctx, parts[1]); err != nil {
+			slog.Error("clear replacement", "error", err)
+			return
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "✅ Замена удалена.",
+		})
+
+	case len(parts) == 3:
+		// The replaced model may already be gone, the replacement must exist
+		if _, err := h.llm.GetModel(ctx, parts[2]); err != nil {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID:    chatID,
+				Text:      fmt.Sprintf("❌ Модель `%s` не найдена.", parts[2]),
+				ParseMode: models.ParseModeMarkdownV1,
+			})
+			return
+		}
+		if err := h.replacements.Set(ctx, parts[1], parts[2]); err != nil {
+			slog.Error("set replacement", "error", err)
+			return
+		}
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      fmt.Sprintf("✅ `%s`\n→ `%s`", parts[1], parts[2]),
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+
+	default:
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "Использование: /replace <модель> <замена>",
+		})
+	}
+}
+
+// MigrateRemovedModels moves users, groups and sessions off models that left
+// the catalogue and tells the affected chats. Each chat is told once, since
+// after the move it no longer uses the old model.
+func (h *Handler) MigrateRemovedModels(ctx context.Context) {
+	migrations, err := h.replacements.MigrateRemoved(ctx)
+	if err != nil {
+		slog.Error("migrate removed models", "error", err)
+	}
+
+	for _, m := range migrations {
+		text := fmt.Sprintf("⚠️ Модель %s больше недоступна, вместо неё теперь используется %s.\n\nВыбрать другую: /models", m.From, m.To)
+		for _, telegramID := range m.Users {
+			h.notifyReplacement(ctx, &bot.SendMessageParams{
+				ChatID: telegramID,
+				Text:   text,
+			})
+		}
+		for _, g := range m.Groups {
+			params := &bot.SendMessageParams{
+				ChatID: g.TelegramID,
+				Text:   text,
+			}
+			if g.ThreadID != nil {
+				params.MessageThreadID = int(*g.ThreadID)
+			}
+			h.notifyReplacement(ctx, params)
+		}
+	}
+}
+
+// notifyReplacement sends one notification, keeping under Telegram's limit on
+// messages per second.
+func (h *Handler) notifyReplacement(ctx context.Context, params *bot.SendMessageParams) {
+	if _, err := h.bot.SendMessage(ctx, params); err != nil {
+		slog.Warn("notify model replacement", "chat", params.ChatID, "error", err)
+	}
+	time.Sleep(config.NotifyInterval)
+}
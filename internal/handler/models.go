@@ -17,6 +17,7 @@ import (
 	"github.com/set-night/mindapp/internal/domain"
 	"github.com/set-night/mindapp/internal/middleware"
 	"github.com/set-night/mindapp/internal/repository/sqlc"
+	"github.com/set-night/mindapp/internal/service"
 	tg "github.com/set-night/mindapp/internal/telegram"
 )
 
@@ -53,7 +54,7 @@ func (h *Handler) handleModels(ctx context.Context, b *bot.Bot, update *models.U
 }
 
 func (h *Handler) sendModelsPage(ctx context.Context, b *bot.Bot, chatID int64, user *domain.User, page int, sortBy string, search string, edit bool, messageID int) {
-	allModels, err := h.openRouter.ListModels(ctx)
+	allModels, err := h.llm.ListModels(ctx)
 	if err != nil {
 		slog.Error("list models", "error", err)
 		b.SendMessage(ctx, &bot.SendMessageParams{
@@ -112,8 +113,12 @@ func (h *Handler) sendModelsPage(ctx context.Context, b *bot.Bot, chatID int64,
 		if m.ID == user.SelectedModel {
 			selected = " ✅"
 		}
-		sb.WriteString(fmt.Sprintf("%s *%s*%s\n💰 %s | 📝 %dk ctx\n\n",
-			caps, m.Name, selected, priceStr, m.ContextLength/1000))
+		provider := ""
+		if m.Provider != service.ProviderOpenRouter {
+			provider = fmt.Sprintf(" | 🖥 %s", m.Provider)
+		}
+		sb.WriteString(fmt.Sprintf("%s *%s*%s\n💰 %s | 📝 %dk ctx%s\n\n",
+			caps, m.Name, selected, priceStr, m.ContextLength/1000, provider))
 	}
 
 	// Build buttons
@@ -217,7 +222,7 @@ func (h *Handler) handleModelSelect(ctx context.Context, b *bot.Bot, update *mod
 	})
 
 	// Check if model exists
-	model, err := h.openRouter.GetModel(ctx, modelID)
+	model, err := h.llm.GetModel(ctx, modelID)
 	if err != nil {
 		b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
 			CallbackQueryID: update.CallbackQuery.ID,
@@ -390,6 +395,12 @@ func modelCapsEmoji(caps domain.ModelCapabilities) string {
 	if caps.Files {
 		emojis = append(emojis, "📎")
 	}
+	if caps.Tools {
+		emojis = append(emojis, "🔧")
+	}
+	if caps.Reasoning {
+		emojis = append(emojis, "🧠")
+	}
 	if len(emojis) == 0 {
 		return "💬"
 	}
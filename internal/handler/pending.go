@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"sync"
+	"time"
+)
+
+// pendingStore holds one value per chat until the user answers a question
+// about it, such as a request waiting for cost confirmation. Values live in
+// memory: a restart only loses the question.
+type pendingStore[T any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[int64]pendingEntry[T]
+	lastID  int64
+}
+
+type pendingEntry[T any] struct {
+	id      int64
+	value   T
+	expires time.Time
+}
+
+func newPendingStore[T any](ttl time.Duration) *pendingStore[T] {
+	return &pendingStore[T]{ttl: ttl, entries: make(map[int64]pendingEntry[T])}
+}
+
+// Put stores the chat's value, replacing an earlier one. It returns the id
+// of the value, which buttons asking about it carry so that a button of a
+// replaced value does not act on the new one.
+func (p *pendingStore[T]) Put(chatID int64, value T) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for id, e := range p.entries {
+		if now.After(e.expires) {
+			delete(p.entries, id)
+		}
+	}
+	p.lastID++
+	p.entries[chatID] = pendingEntry[T]{id: p.lastID, value: value, expires: now.Add(p.ttl)}
+	return p.lastID
+}
+
+// Take removes and returns the chat's value.
+func (p *pendingStore[T]) Take(chatID int64) (T, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.entries[chatID]
+	delete(p.entries, chatID)
+	if !ok || time.Now().After(e.expires) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
+
+// TakeID removes and returns the chat's value if it is the one Put returned
+// id for. A newer value is left in place.
+func (p *pendingStore[T]) TakeID(chatID, id int64) (T, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.entries[chatID]
+	if !ok || e.id != id {
+		var zero T
+		return zero, false
+	}
+	delete(p.entries, chatID)
+	if time.Now().After(e.expires) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPendingStoreTakeID(t *testing.T) {
+	p := newPendingStore[string](time.Hour)
+
+	old := p.Put(1, "old")
+	current := p.Put(1, "current")
+	if old == current {
+		t.Fatalf("Put returned the same id %d twice", old)
+	}
+
+	if _, ok := p.TakeID(1, old); ok {
+		t.Fatal("TakeID with the id of a replaced value succeeded")
+	}
+	if v, ok := p.TakeID(1, current); !ok || v != "current" {
+		t.Fatalf("TakeID(current) = %q, %v, want current, true", v, ok)
+	}
+	if _, ok := p.TakeID(1, current); ok {
+		t.Fatal("TakeID succeeded twice")
+	}
+}
+
+func TestPendingStoreExpiry(t *testing.T) {
+	p := newPendingStore[string](-time.Second)
+
+	id := p.Put(1, "value")
+	if _, ok := p.TakeID(1, id); ok {
+		t.Fatal("TakeID returned an expired value")
+	}
+	p.Put(2, "value")
+	if _, ok := p.Take(2); ok {
+		t.Fatal("Take returned an expired value")
+	}
+}
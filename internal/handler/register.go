@@ -15,7 +15,10 @@ func (h *Handler) Register() {
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/models", bot.MatchTypePrefix, h.handleModels)
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/sessions", bot.MatchTypePrefix, h.handleSessions)
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/favorite", bot.MatchTypePrefix, h.handleFavorite)
+	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/alias", bot.MatchTypePrefix, h.handleAlias)
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/end", bot.MatchTypePrefix, h.handleEnd)
+	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/compare", bot.MatchTypePrefix, h.handleCompare)
+	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/params", bot.MatchTypePrefix, h.handleParams)
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/pay", bot.MatchTypePrefix, h.handlePay)
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/premium", bot.MatchTypePrefix, h.handlePremium)
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/promo", bot.MatchTypePrefix, h.handlePromo)
@@ -25,11 +28,27 @@ func (h *Handler) Register() {
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/free", bot.MatchTypePrefix, h.handleFreeTasks)
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/stat", bot.MatchTypePrefix, h.handleStat)
 	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/promoCreate", bot.MatchTypePrefix, h.handlePromoCreate)
+	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/fallback", bot.MatchTypePrefix, h.handleFallback)
+	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/circuits", bot.MatchTypePrefix, h.handleCircuits)
+	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/modelchanges", bot.MatchTypePrefix, h.handleModelChanges)
+	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/replace", bot.MatchTypePrefix, h.handleReplace)
+	h.bot.RegisterHandler(bot.HandlerTypeMessageText, "/drift", bot.MatchTypePrefix, h.handleDrift)
 
 	// Settings callbacks
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "toggle_context", bot.MatchTypePrefix, h.handleToggleContext)
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "toggle_cost", bot.MatchTypePrefix, h.handleToggleCost)
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "toggle_send_user_info", bot.MatchTypePrefix, h.handleToggleSendUserInfo)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "toggle_voice_replies", bot.MatchTypePrefix, h.handleToggleVoiceReplies)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "toggle_show_reasoning", bot.MatchTypePrefix, h.handleToggleShowReasoning)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "cycle_reasoning_effort", bot.MatchTypePrefix, h.handleCycleReasoningEffort)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "cycle_max_cost", bot.MatchTypePrefix, h.handleCycleMaxCost)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "cost_confirm_", bot.MatchTypePrefix, h.handleCostConfirm)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "compare_pick_", bot.MatchTypePrefix, h.handleComparePick)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "answer_regen_", bot.MatchTypePrefix, h.handleAnswerAction)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "answer_continue_", bot.MatchTypePrefix, h.handleAnswerAction)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "answer_cancel", bot.MatchTypeExact, h.handleCancelRequest)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "session_params", bot.MatchTypePrefix, h.handleSessionParams)
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "sp_", bot.MatchTypePrefix, h.handleSessionParamButton)
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "set_temperature", bot.MatchTypePrefix, h.handleSetTemperature)
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "temp_", bot.MatchTypePrefix, h.handleTempValue)
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "set_timeout_", bot.MatchTypePrefix, h.handleSetTimeout)
@@ -49,6 +68,9 @@ func (h *Handler) Register() {
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "switch_session_", bot.MatchTypePrefix, h.handleSwitchSession)
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "sessions_page_", bot.MatchTypePrefix, h.handleSessionsPage)
 
+	// Voice reply callbacks
+	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "show_text_", bot.MatchTypePrefix, h.handleShowText)
+
 	// Favorite callbacks
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "fav_select_", bot.MatchTypePrefix, h.handleFavSelect)
 	h.bot.RegisterHandler(bot.HandlerTypeCallbackQueryData, "fav_remove_", bot.MatchTypePrefix, h.handleFavRemove)
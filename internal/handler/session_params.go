@@ -0,0 +1,360 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/service"
+	tg "github.com/set-night/mindapp/internal/telegram"
+)
+
+// Values the parameter buttons cycle through; zero leaves the parameter unset.
+var (
+	topPOptions      = []float64{0, 0.1, 0.5, 0.9, 1}
+	maxTokensOptions = []int{0, 256, 1024, 4096, 16384}
+	penaltyOptions   = []float64{0, 0.5, 1, 1.5, 2}
+)
+
+var responseFormatLabels = map[string]string{
+	"":            "Текст",
+	"json_object": "JSON",
+	"json_schema": "JSON-схема",
+}
+
+const sessionParamsUsage = "Остальное задаётся командой:\n" +
+	"/params top_p 0.8\n" +
+	"/params max_tokens 2000\n" +
+	"/params frequency 0.5 и /params presence 0.5\n" +
+	"/params seed 42\n" +
+	"/params stop ### | КОНЕЦ\n" +
+	"/params schema {\"type\": \"object\", ...}\n" +
+	"/params <параметр> - — сбросить параметр, /params reset — все"
+
+// handleParams shows or edits the generation parameters of the active session.
+//
+//	/params                  — show the parameters
+//	/params <name> <value>   — set one (top_p, max_tokens, frequency, presence, seed, stop, schema)
+//	/params <name> -         — unset it
+//	/params reset            — unset all
+func (h *Handler) handleParams(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil || update.Message.Chat.Type != "private" {
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+	chatID := update.Message.Chat.ID
+
+	session, err := h.sessionService.FindOrCreate(ctx, user)
+	if err != nil {
+		slog.Error("find or create session", "error", err)
+		return
+	}
+
+	// The first field is the command itself, possibly as /params@botname
+	if parts := strings.Fields(update.Message.Text); len(parts) > 1 {
+		name, value := parts[1], strings.Join(parts[2:], " ")
+		if err := setSessionParam(&session.Params, strings.ToLower(name), value); err != nil {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "❌ " + err.Error() + "\n\n" + sessionParamsUsage,
+			})
+			return
+		}
+		if err := h.sessionService.UpdateParams(ctx, session.ID, session.Params); err != nil {
+			slog.Error("update session params", "error", err)
+			return
+		}
+	}
+
+	h.sendSessionParams(ctx, b, chatID, 0, session)
+}
+
+// setSessionParam parses one parameter from a /params command. The error
+// text is shown to the user.
+func setSessionParam(p *domain.SessionParams, name, value string) error {
+	if name == "reset" {
+		*p = domain.SessionParams{}
+		return nil
+	}
+	unset := value == "-"
+
+	switch name {
+	case "top_p":
+		if unset {
+			p.TopP = nil
+			return nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil || v <= 0 || v > 1 {
+			return fmt.Errorf("top_p должен быть числом от 0 до 1")
+		}
+		p.TopP = &v
+	case "max_tokens":
+		if unset {
+			p.MaxTokens = nil
+			return nil
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil || v <= 0 {
+			return fmt.Errorf("max_tokens должен быть положительным числом")
+		}
+		p.MaxTokens = &v
+	case "frequency", "presence":
+		target := &p.FrequencyPenalty
+		if name == "presence" {
+			target = &p.PresencePenalty
+		}
+		if unset {
+			*target = nil
+			return nil
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil || v < -2 || v > 2 {
+			return fmt.Errorf("штраф должен быть числом от -2 до 2")
+		}
+		*target = &v
+	case "seed":
+		if unset {
+			p.Seed = nil
+			return nil
+		}
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("seed должен быть целым числом")
+		}
+		p.Seed = &v
+	case "stop":
+		if unset {
+			p.Stop = nil
+			return nil
+		}
+		var stop []string
+		for _, s := range strings.Split(value, "|") {
+			if s = strings.TrimSpace(s); s != "" {
+				stop = append(stop, s)
+			}
+		}
+		if len(stop) == 0 || len(stop) > 4 {
+			return fmt.Errorf("укажите от 1 до 4 стоп-последовательностей через |")
+		}
+		p.Stop = stop
+	case "schema":
+		if unset {
+			p.JSONSchema = nil
+			if p.ResponseFormat == "json_schema" {
+				p.ResponseFormat = ""
+			}
+			return nil
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &schema); err != nil {
+			return fmt.Errorf("схема должна быть JSON-объектом: %v", err)
+		}
+		p.JSONSchema = json.RawMessage(value)
+		p.ResponseFormat = "json_schema"
+	default:
+		return fmt.Errorf("неизвестный параметр %q", name)
+	}
+	return nil
+}
+
+// sendSessionParams shows the parameter panel, replacing messageID if set.
+func (h *Handler) sendSessionParams(ctx context.Context, b *bot.Bot, chatID int64, messageID int, session *domain.ChatSession) {
+	p := session.Params
+	var caps domain.ModelCapabilities
+	if model, err := h.llm.GetModel(ctx, session.Model); err == nil {
+		caps = model.Capabilities
+	}
+	line := func(name, value string, set, supported bool) string {
+		if !set {
+			value = "по умолчанию"
+		}
+		text := fmt.Sprintf("• %s: %s", name, value)
+		if set && !supported {
+			text += " ⚠️ не поддерживается моделью"
+		}
+		return text + "\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🎛 Параметры генерации текущего диалога (%s)\n\n", session.Model))
+	sb.WriteString(line("top_p", fmtOptFloat(p.TopP), p.TopP != nil, caps.TopP))
+	sb.WriteString(line("max_tokens", fmtOptInt(p.MaxTokens), p.MaxTokens != nil, caps.MaxTokens))
+	sb.WriteString(line("frequency_penalty", fmtOptFloat(p.FrequencyPenalty), p.FrequencyPenalty != nil, caps.Penalties))
+	sb.WriteString(line("presence_penalty", fmtOptFloat(p.PresencePenalty), p.PresencePenalty != nil, caps.Penalties))
+	seed := ""
+	if p.Seed != nil {
+		seed = strconv.FormatInt(*p.Seed, 10)
+	}
+	sb.WriteString(line("seed", seed, p.Seed != nil, caps.Seed))
+	sb.WriteString(line("stop", strings.Join(p.Stop, " | "), len(p.Stop) > 0, caps.Stop))
+	formatSupported := (p.ResponseFormat == "json_object" && (caps.JSONMode || caps.StructuredOutputs)) ||
+		(p.ResponseFormat == "json_schema" && caps.StructuredOutputs)
+	format := responseFormatLabels[p.ResponseFormat]
+	if p.ResponseFormat == "json_schema" {
+		// Only schemas that meet the strict rules are enforced strictly
+		if service.StrictSchema(p.JSONSchema) {
+			format += " (strict)"
+		} else {
+			format += " (не strict: у объектов нужны additionalProperties: false и все свойства в required)"
+		}
+	}
+	sb.WriteString(line("формат ответа", format, p.ResponseFormat != "", formatSupported))
+	sb.WriteString("\n" + sessionParamsUsage)
+
+	rows := [][]models.InlineKeyboardButton{
+		tg.ButtonRow(
+			tg.InlineButton("top_p: "+fmtOptFloat(p.TopP), "sp_top_p"),
+			tg.InlineButton("max_tokens: "+fmtOptInt(p.MaxTokens), "sp_max_tokens"),
+		),
+		tg.ButtonRow(
+			tg.InlineButton("frequency: "+fmtOptFloat(p.FrequencyPenalty), "sp_frequency"),
+			tg.InlineButton("presence: "+fmtOptFloat(p.PresencePenalty), "sp_presence"),
+		),
+		tg.ButtonRow(tg.InlineButton("📄 Формат: "+responseFormatLabels[p.ResponseFormat], "sp_format")),
+		tg.ButtonRow(tg.InlineButton("♻️ Сбросить всё", "sp_reset")),
+	}
+
+	if messageID != 0 {
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        sb.String(),
+			ReplyMarkup: tg.InlineKeyboard(rows...),
+		})
+		return
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        sb.String(),
+		ReplyMarkup: tg.InlineKeyboard(rows...),
+	})
+}
+
+// handleSessionParams opens the parameter panel from /settings.
+func (h *Handler) handleSessionParams(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	msg := update.CallbackQuery.Message.Message
+	if user == nil || msg == nil {
+		return
+	}
+
+	session, err := h.sessionService.FindOrCreate(ctx, user)
+	if err != nil {
+		slog.Error("find or create session", "error", err)
+		return
+	}
+	h.sendSessionParams(ctx, b, msg.Chat.ID, 0, session)
+}
+
+// handleSessionParamButton cycles the parameter on a panel button.
+func (h *Handler) handleSessionParamButton(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	msg := update.CallbackQuery.Message.Message
+	if user == nil || msg == nil {
+		return
+	}
+
+	session, err := h.sessionService.FindOrCreate(ctx, user)
+	if err != nil {
+		slog.Error("find or create session", "error", err)
+		return
+	}
+
+	p := &session.Params
+	switch strings.TrimPrefix(update.CallbackQuery.Data, "sp_") {
+	case "top_p":
+		p.TopP = nextFloatOption(topPOptions, p.TopP)
+	case "max_tokens":
+		current := 0
+		if p.MaxTokens != nil {
+			current = *p.MaxTokens
+		}
+		p.MaxTokens = nil
+		for i, v := range maxTokensOptions {
+			if v == current {
+				if next := maxTokensOptions[(i+1)%len(maxTokensOptions)]; next != 0 {
+					p.MaxTokens = &next
+				}
+				break
+			}
+		}
+	case "frequency":
+		p.FrequencyPenalty = nextFloatOption(penaltyOptions, p.FrequencyPenalty)
+	case "presence":
+		p.PresencePenalty = nextFloatOption(penaltyOptions, p.PresencePenalty)
+	case "format":
+		// The schema format needs a schema, set with /params schema
+		switch {
+		case p.ResponseFormat == "":
+			p.ResponseFormat = "json_object"
+		case p.ResponseFormat == "json_object" && p.JSONSchema != nil:
+			p.ResponseFormat = "json_schema"
+		default:
+			p.ResponseFormat = ""
+		}
+	case "reset":
+		*p = domain.SessionParams{}
+	}
+
+	if err := h.sessionService.UpdateParams(ctx, session.ID, session.Params); err != nil {
+		slog.Error("update session params", "error", err)
+		return
+	}
+	h.sendSessionParams(ctx, b, msg.Chat.ID, msg.ID, session)
+}
+
+// nextFloatOption returns the option after current; a value that is not an
+// option starts over, and zero means unset.
+func nextFloatOption(options []float64, current *float64) *float64 {
+	cur := 0.0
+	if current != nil {
+		cur = *current
+	}
+	next := options[0]
+	for i, v := range options {
+		if v == cur {
+			next = options[(i+1)%len(options)]
+			break
+		}
+	}
+	if next == 0 {
+		return nil
+	}
+	return &next
+}
+
+func fmtOptFloat(v *float64) string {
+	if v == nil {
+		return "—"
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func fmtOptInt(v *int) string {
+	if v == nil {
+		return "—"
+	}
+	return strconv.Itoa(*v)
+}
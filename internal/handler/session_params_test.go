@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+func TestSetSessionParam(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+	intPtr := func(v int) *int { return &v }
+	seed := int64(42)
+
+	tests := []struct {
+		name    string
+		start   domain.SessionParams
+		param   string
+		value   string
+		want    domain.SessionParams
+		wantErr bool
+	}{
+		{name: "top_p", param: "top_p", value: "0.8", want: domain.SessionParams{TopP: ptr(0.8)}},
+		{name: "top_p out of range", param: "top_p", value: "1.5", wantErr: true},
+		{name: "top_p zero", param: "top_p", value: "0", wantErr: true},
+		{name: "max_tokens", param: "max_tokens", value: "2000", want: domain.SessionParams{MaxTokens: intPtr(2000)}},
+		{name: "max_tokens negative", param: "max_tokens", value: "-1", wantErr: true},
+		{name: "frequency", param: "frequency", value: "0.5", want: domain.SessionParams{FrequencyPenalty: ptr(0.5)}},
+		{name: "presence", param: "presence", value: "-2", want: domain.SessionParams{PresencePenalty: ptr(-2)}},
+		{name: "penalty out of range", param: "presence", value: "3", wantErr: true},
+		{name: "seed", param: "seed", value: "42", want: domain.SessionParams{Seed: &seed}},
+		{name: "seed not a number", param: "seed", value: "x", wantErr: true},
+		{name: "stop", param: "stop", value: "### | КОНЕЦ |", want: domain.SessionParams{Stop: []string{"###", "КОНЕЦ"}}},
+		{name: "too many stops", param: "stop", value: "a|b|c|d|e", wantErr: true},
+		{
+			name:  "schema",
+			param: "schema",
+			value: `{"type":"object"}`,
+			want:  domain.SessionParams{ResponseFormat: "json_schema", JSONSchema: []byte(`{"type":"object"}`)},
+		},
+		{name: "schema not an object", param: "schema", value: `[1]`, wantErr: true},
+		{
+			name:  "unset schema",
+			start: domain.SessionParams{ResponseFormat: "json_schema", JSONSchema: []byte(`{}`)},
+			param: "schema",
+			value: "-",
+			want:  domain.SessionParams{},
+		},
+		{name: "unset", start: domain.SessionParams{TopP: ptr(0.5), Seed: &seed}, param: "top_p", value: "-", want: domain.SessionParams{Seed: &seed}},
+		{name: "reset", start: domain.SessionParams{TopP: ptr(0.5), Seed: &seed, Stop: []string{"x"}}, param: "reset", want: domain.SessionParams{}},
+		{name: "unknown", param: "temperature", value: "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.start
+			err := setSessionParam(&p, tt.param, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setSessionParam() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !reflect.DeepEqual(p, tt.start) {
+					t.Errorf("params changed on error: %+v", p)
+				}
+				return
+			}
+			if !reflect.DeepEqual(p, tt.want) {
+				t.Errorf("params = %+v, want %+v", p, tt.want)
+			}
+		})
+	}
+}
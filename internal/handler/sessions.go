@@ -7,6 +7,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -56,16 +57,13 @@ func (h *Handler) sendSessionsPage(ctx context.Context, b *bot.Bot, chatID int64
 	sb.WriteString(fmt.Sprintf("📂 *Сессии* (%d шт.)\n\n", total))
 
 	var rows [][]models.InlineKeyboardButton
+	var forks []string
 
 	for _, s := range sessions {
-		firstMsg, _ := h.sessionService.GetFirstMessage(ctx, s.ID)
-		label := fmt.Sprintf("📝 %s", s.CreatedAt.Format("02.01 15:04"))
-		if firstMsg != nil && firstMsg.Text != "" {
-			snippet := firstMsg.Text
-			if len(snippet) > 30 {
-				snippet = snippet[:30] + "..."
-			}
-			label = snippet
+		label := h.sessionLabel(ctx, s.ID, s.CreatedAt)
+		if s.ParentSessionID != nil {
+			label = "🌿 " + label
+			forks = append(forks, h.forkDescription(ctx, user, &s))
 		}
 		active := ""
 		if user.ActiveSessionID != nil && *user.ActiveSessionID == s.ID {
@@ -76,6 +74,13 @@ func (h *Handler) sendSessionsPage(ctx context.Context, b *bot.Bot, chatID int64
 		))
 	}
 
+	if len(forks) > 0 {
+		sb.WriteString("🌿 *Ветки* — диалоги, продолженные ответом на старое сообщение:\n")
+		for _, f := range forks {
+			sb.WriteString("• " + f + "\n")
+		}
+	}
+
 	// Action buttons
 	actionRow := []models.InlineKeyboardButton{
 		tg.InlineButton("➕ Новая", "new_session"),
@@ -251,3 +256,40 @@ func (h *Handler) handleSessionsPage(ctx context.Context, b *bot.Bot, update *mo
 
 	h.sendSessionsPage(ctx, b, chatID, user, page, true, messageID)
 }
+
+// sessionLabel names a session by the start of its first message.
+func (h *Handler) sessionLabel(ctx context.Context, sessionID int64, createdAt time.Time) string {
+	firstMsg, _ := h.sessionService.GetFirstMessage(ctx, sessionID)
+	if firstMsg == nil || firstMsg.Text == "" {
+		return fmt.Sprintf("📝 %s", createdAt.Format("02.01 15:04"))
+	}
+	return snippet(firstMsg.Text, 30)
+}
+
+// forkDescription tells which session and answer a branch started from.
+func (h *Handler) forkDescription(ctx context.Context, user *domain.User, s *domain.ChatSession) string {
+	text := fmt.Sprintf("%s — ветка", s.CreatedAt.Format("02.01 15:04"))
+	if parent, err := h.sessionService.GetByID(ctx, *s.ParentSessionID); err == nil {
+		text += fmt.Sprintf(" диалога «%s»", escapeMarkdown(h.sessionLabel(ctx, parent.ID, parent.CreatedAt)))
+	}
+	if s.ForkMessageID != nil {
+		if answer, err := h.sessionService.GetUserMessage(ctx, user.ID, *s.ForkMessageID); err == nil {
+			text += fmt.Sprintf(" с ответа «%s»", escapeMarkdown(snippet(answer.Text, 40)))
+		}
+	}
+	return text
+}
+
+// snippet cuts text to at most n characters on one line.
+func snippet(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return text
+}
+
+// escapeMarkdown escapes user text for the legacy Markdown parse mode.
+func escapeMarkdown(text string) string {
+	return strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[").Replace(text)
+}
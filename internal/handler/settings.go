@@ -80,6 +80,35 @@ func (h *Handler) sendUserSettings(ctx context.Context, b *bot.Bot, chatID int64
 	rows = append(rows, tg.ButtonRow(
 		tg.InlineButton(fmt.Sprintf("👤 Отправка данных: %s", userInfoStatus), "toggle_send_user_info"),
 	))
+	if h.tts.Enabled() {
+		voiceStatus := "❌ Выкл"
+		if user.VoiceReplies {
+			voiceStatus = "✅ Вкл"
+		}
+		rows = append(rows, tg.ButtonRow(
+			tg.InlineButton(fmt.Sprintf("🔊 Голосовые ответы: %s", voiceStatus), "toggle_voice_replies"),
+		))
+	}
+	reasoningStatus := "❌ Скрыты"
+	if user.ShowReasoning {
+		reasoningStatus = "✅ Показывать"
+	}
+	rows = append(rows, tg.ButtonRow(
+		tg.InlineButton(fmt.Sprintf("🧠 Рассуждения: %s", reasoningStatus), "toggle_show_reasoning"),
+	))
+	rows = append(rows, tg.ButtonRow(
+		tg.InlineButton(fmt.Sprintf("🎚 Усилие рассуждений: %s", reasoningEffortLabels[user.ReasoningEffort]), "cycle_reasoning_effort"),
+	))
+	maxCostStatus := "Нет"
+	if user.MaxRequestCost.IsPositive() {
+		maxCostStatus = "$" + user.MaxRequestCost.String()
+	}
+	rows = append(rows, tg.ButtonRow(
+		tg.InlineButton(fmt.Sprintf("💸 Лимит на запрос: %s", maxCostStatus), "cycle_max_cost"),
+	))
+	rows = append(rows, tg.ButtonRow(
+		tg.InlineButton("🎛 Параметры генерации", "session_params"),
+	))
 
 	if user.IsPremium() {
 		rows = append(rows, tg.ButtonRow(
@@ -247,6 +276,132 @@ func (h *Handler) handleToggleSendUserInfo(ctx context.Context, b *bot.Bot, upda
 	h.sendUserSettings(ctx, b, chatID)
 }
 
+func (h *Handler) handleToggleVoiceReplies(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+
+	var chatID int64
+	if msg := update.CallbackQuery.Message.Message; msg != nil {
+		chatID = msg.Chat.ID
+	}
+
+	h.queries.ToggleUserVoiceReplies(ctx, user.ID)
+	user.VoiceReplies = !user.VoiceReplies
+	h.sendUserSettings(ctx, b, chatID)
+}
+
+func (h *Handler) handleToggleShowReasoning(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+
+	var chatID int64
+	if msg := update.CallbackQuery.Message.Message; msg != nil {
+		chatID = msg.Chat.ID
+	}
+
+	h.queries.ToggleUserShowReasoning(ctx, user.ID)
+	user.ShowReasoning = !user.ShowReasoning
+	h.sendUserSettings(ctx, b, chatID)
+}
+
+// reasoningEfforts is the order the effort button cycles through; empty leaves
+// the choice to the model.
+var reasoningEfforts = []string{"", "low", "medium", "high"}
+
+var reasoningEffortLabels = map[string]string{
+	"":       "По умолчанию",
+	"low":    "Низкое",
+	"medium": "Среднее",
+	"high":   "Высокое",
+}
+
+func (h *Handler) handleCycleReasoningEffort(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+
+	var chatID int64
+	if msg := update.CallbackQuery.Message.Message; msg != nil {
+		chatID = msg.Chat.ID
+	}
+
+	next := reasoningEfforts[0]
+	for i, effort := range reasoningEfforts {
+		if effort == user.ReasoningEffort {
+			next = reasoningEfforts[(i+1)%len(reasoningEfforts)]
+		}
+	}
+
+	h.queries.SetUserReasoningEffort(ctx, sqlc.SetUserReasoningEffortParams{
+		ID:              user.ID,
+		ReasoningEffort: next,
+	})
+	user.ReasoningEffort = next
+	h.sendUserSettings(ctx, b, chatID)
+}
+
+// maxRequestCosts is the order the cost limit button cycles through; zero
+// means no limit.
+var maxRequestCosts = []decimal.Decimal{
+	decimal.Zero,
+	decimal.RequireFromString("0.01"),
+	decimal.RequireFromString("0.05"),
+	decimal.RequireFromString("0.1"),
+	decimal.RequireFromString("0.5"),
+	decimal.RequireFromString("1"),
+}
+
+func (h *Handler) handleCycleMaxCost(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+
+	var chatID int64
+	if msg := update.CallbackQuery.Message.Message; msg != nil {
+		chatID = msg.Chat.ID
+	}
+
+	next := maxRequestCosts[0]
+	for i, cost := range maxRequestCosts {
+		if cost.Equal(user.MaxRequestCost) {
+			next = maxRequestCosts[(i+1)%len(maxRequestCosts)]
+		}
+	}
+
+	h.queries.SetUserMaxRequestCost(ctx, sqlc.SetUserMaxRequestCostParams{
+		ID:             user.ID,
+		MaxRequestCost: next,
+	})
+	user.MaxRequestCost = next
+	h.sendUserSettings(ctx, b, chatID)
+}
+
 func (h *Handler) handleSetTemperature(ctx context.Context, b *bot.Bot, update *models.Update) {
 	if update.CallbackQuery == nil {
 		return
@@ -299,6 +454,12 @@ func (h *Handler) handleTempValue(ctx context.Context, b *bot.Bot, update *model
 		ID:          user.ID,
 		Temperature: decimal.NewFromFloat(temp),
 	})
+	// The active session keeps its own temperature, so change it as well
+	if user.ActiveSessionID != nil {
+		if err := h.sessionService.UpdateTemperature(ctx, *user.ActiveSessionID, temp); err != nil {
+			slog.Error("update session temperature", "error", err)
+		}
+	}
 
 	var chatID int64
 	if msg := update.CallbackQuery.Message.Message; msg != nil {
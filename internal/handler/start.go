@@ -13,6 +13,7 @@ import (
 	"github.com/go-telegram/bot/models"
 	"github.com/set-night/mindapp/internal/domain"
 	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/service"
 )
 
 func (h *Handler) handleStart(ctx context.Context, b *bot.Bot, update *models.Update) {
@@ -78,6 +79,9 @@ func (h *Handler) handleStartPrivate(ctx context.Context, b *bot.Bot, update *mo
 			"/sessions — Управление сессиями\n"+
 			"/settings — Настройки\n"+
 			"/favorite — Избранные модели\n"+
+			"/alias — Псевдонимы моделей для @вопросов\n"+
+			"/compare — Сравнить ответы моделей\n"+
+			"/params — Параметры генерации диалога\n"+
 			"/pay — Пополнить баланс\n"+
 			"/premium — Премиум подписка\n"+
 			"/referral — Реферальная программа\n"+
@@ -157,13 +161,7 @@ func (h *Handler) activatePrompt(ctx context.Context, b *bot.Bot, chatID int64,
 		return
 	}
 
-	// Gemini models don't support "system" role; use "user" instead
-	role := "system"
-	if strings.Contains(strings.ToLower(session.Model), "gemini") {
-		role = "user"
-	}
-
-	_, err = h.sessionService.AddMessage(ctx, session.ID, role, prompt.PromptText, nil, true)
+	_, err = h.sessionService.AddMessage(ctx, session.ID, service.SystemRole(session.Model), prompt.PromptText, nil, true)
 	if err != nil {
 		slog.Error("add system prompt message", "error", err)
 		return
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-telegram/bot"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/service"
+	tg "github.com/set-night/mindapp/internal/telegram"
+	"github.com/shopspring/decimal"
+)
+
+// summarizeSession compresses the oldest messages of a full session into a
+// summary and charges the user for it. It returns false if the session could
+// not be compressed and has to be reset instead.
+func (h *Handler) summarizeSession(ctx context.Context, b *bot.Bot, chatID int64, user *domain.User, session *domain.ChatSession, maxMessages int) bool {
+	// The summary is paid, so it needs some balance
+	model, err := h.llm.GetModel(ctx, h.cfg.SummaryModel)
+	if err != nil {
+		slog.Error("get summary model", "error", err, "model", h.cfg.SummaryModel)
+		return false
+	}
+	if !model.IsFree() && !user.Balance.IsPositive() {
+		return false
+	}
+
+	statusMsg, _ := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "🗜 Сжимаю историю диалога...",
+	})
+	report := func(text string) {
+		if statusMsg == nil {
+			b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text})
+			return
+		}
+		tg.EditLongMessage(ctx, b, chatID, statusMsg.ID, text, nil)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, config.RequestTimeout)
+	defer cancel()
+
+	usage, model, err := h.summary.Summarize(reqCtx, session, config.SummaryKeepMessages)
+	if err != nil {
+		slog.Error("summarize session", "error", err, "session", session.ID)
+		if statusMsg != nil {
+			b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: statusMsg.ID})
+		}
+		return false
+	}
+
+	text := fmt.Sprintf("📝 Достигнут лимит сообщений (%d). Старые сообщения сжаты в краткое содержание, диалог продолжается.", maxMessages)
+
+	// Charge for the summary like a regular request
+	if !model.IsFree() {
+		markupPercent := h.cfg.MarkupPercentNormal
+		if user.IsPremium() {
+			markupPercent = h.cfg.MarkupPercentPremium
+		}
+		baseCost := service.CalculateCost(usage, model, 0)
+		if usage.TotalCost > 0 {
+			baseCost = decimal.NewFromFloat(usage.TotalCost)
+		}
+
+		cost, _, err := h.billingService.ProcessUserTransaction(ctx, user.ID, baseCost.InexactFloat64(), markupPercent, fmt.Sprintf("Context summary: %s", model.ID))
+		if err != nil {
+			slog.Error("charge for summary", "error", err)
+		} else {
+			text += fmt.Sprintf("\n💰 Стоимость сжатия: $%.6f", cost.InexactFloat64())
+			h.trackCharge(ctx, service.GenerationCharge{
+				UserID:        &user.ID,
+				Model:         model,
+				Usage:         usage,
+				Charged:       cost,
+				MarkupPercent: markupPercent,
+			})
+		}
+	}
+
+	report(text)
+	return true
+}
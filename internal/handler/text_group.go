@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -59,7 +60,7 @@ func (h *Handler) HandleTextGroup(ctx context.Context, b *bot.Bot, update *model
 	defer h.queries.RemoveActiveRequest(ctx, chatID)
 
 	// 2. Get model info
-	model, err := h.openRouter.GetModel(ctx, group.SelectedModel)
+	model, err := h.llm.GetModel(ctx, group.SelectedModel)
 	if err != nil {
 		slog.Error("get group model", "error", err, "model", group.SelectedModel)
 		return
@@ -139,13 +140,24 @@ func (h *Handler) HandleTextGroup(ctx context.Context, b *bot.Bot, update *model
 	stopTyping := tg.StartTyping(ctx, b, chatID)
 	defer stopTyping()
 
-	// 8. Call OpenRouter
+	// 8. Call the model
 	reqCtx, cancel := context.WithTimeout(ctx, config.RequestTimeout)
 	defer cancel()
 
-	aiResp, err := h.openRouter.Chat(reqCtx, chatMessages, group.SelectedModel, nil)
+	chatReq := service.ChatRequest{
+		Model:    group.SelectedModel,
+		Messages: chatMessages,
+	}
+	if model.Capabilities.ImageGeneration {
+		chatReq.Modalities = []string{"image", "text"}
+	}
+	aiResp, usedModel, err := h.fallbackService.Chat(reqCtx, chatReq)
 	if err != nil {
-		slog.Error("openrouter group chat", "error", err)
+		slog.Error("llm group chat", "error", err)
+		var upErr *service.UpstreamError
+		if errors.As(err, &upErr) && upErr.Kind == service.UpstreamAuth {
+			h.tgLogger.LogError(err, "LLM provider rejected credentials")
+		}
 		return
 	}
 
@@ -154,6 +166,10 @@ func (h *Handler) HandleTextGroup(ctx context.Context, b *bot.Bot, update *model
 	}
 
 	responseText := aiResp.Choices[0].Message.Content
+	images := aiResp.ImageURLs()
+
+	// Bill at the model that actually answered
+	model = usedModel
 
 	// 9. Calculate cost and process transaction
 	markupPercent := h.cfg.MarkupPercentNormal
@@ -165,25 +181,12 @@ func (h *Handler) HandleTextGroup(ctx context.Context, b *bot.Bot, update *model
 	var newBalance decimal.Decimal
 
 	if !model.IsFree() {
-		totalCost = service.CalculateCost(
-			aiResp.Usage.PromptTokens,
-			aiResp.Usage.CompletionTokens,
-			model.PromptPrice,
-			model.CompletionPrice,
-			markupPercent,
-		)
-
-		if aiResp.Usage.TotalCost > 0 {
-			baseCost := decimal.NewFromFloat(aiResp.Usage.TotalCost)
-			markup := decimal.NewFromFloat(1 + markupPercent/100)
-			totalCost = baseCost.Mul(markup)
-		}
-
-		_, newBalance, err = h.billingService.ProcessGroupTransaction(
+		baseCost := service.AnswerCost(aiResp.Usage, model, len(images), 0, 0)
+		totalCost, newBalance, err = h.billingService.ProcessGroupTransaction(
 			ctx, group.ID,
-			totalCost.InexactFloat64()/(1+markupPercent/100),
+			baseCost.InexactFloat64(),
 			markupPercent,
-			fmt.Sprintf("AI request: %s", group.SelectedModel),
+			fmt.Sprintf("AI request: %s", model.ID),
 		)
 		if err != nil {
 			if err == domain.ErrInsufficientBalance {
@@ -194,6 +197,13 @@ func (h *Handler) HandleTextGroup(ctx context.Context, b *bot.Bot, update *model
 			}
 			return
 		}
+		h.trackCharge(ctx, service.GenerationCharge{
+			GroupID:       &group.ID,
+			Model:         model,
+			Usage:         aiResp.Usage,
+			Charged:       totalCost,
+			MarkupPercent: markupPercent,
+		})
 	}
 
 	// 10. Save to group context
@@ -214,7 +224,22 @@ func (h *Handler) HandleTextGroup(ctx context.Context, b *bot.Bot, update *model
 
 	// 11. Send response
 	replyToID := msg.ID
-	tg.SendLongMessage(ctx, b, chatID, responseText, &replyToID)
+	if responseText != "" {
+		tg.SendLongMessage(ctx, b, chatID, responseText, &replyToID)
+	}
+	if len(images) > 0 {
+		if _, err := tg.SendImages(ctx, b, chatID, images); err != nil {
+			slog.Error("send generated images", "error", err)
+		}
+	}
+
+	if model.ID != group.SelectedModel {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    chatID,
+			Text:      fmt.Sprintf("↪️ Основная модель недоступна, ответила `%s`.", model.ID),
+			ParseMode: models.ParseModeMarkdownV1,
+		})
+	}
 
 	// 12. Show cost if enabled
 	if group.ShowCost && !model.IsFree() {
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
@@ -42,8 +44,154 @@ func (h *Handler) HandleTextPrivate(ctx context.Context, b *bot.Bot, update *mod
 		return
 	}
 
-	chatID := msg.Chat.ID
+	req, ok := h.messageRequest(ctx, b, user, msg)
+	if !ok {
+		return
+	}
+	h.handlePrivateRequest(ctx, b, msg.Chat.ID, user, req)
+}
+
+// HandleEditedPrivate re-runs the last prompt when the user edits it: the
+// prompt and its answer are replaced and the answer is rewritten in place.
+func (h *Handler) HandleEditedPrivate(ctx context.Context, b *bot.Bot, update *models.Update) {
+	msg := update.EditedMessage
+	if msg == nil || msg.Chat.Type != "private" || strings.HasPrefix(msg.Text, "/") {
+		return
+	}
+	// Only the caption of a voice message can change, the recording is the prompt
+	if msg.Voice != nil || msg.Audio != nil || msg.VideoNote != nil {
+		return
+	}
+
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+
+	req, ok := h.messageRequest(ctx, b, user, msg)
+	if !ok {
+		return
+	}
+	req.Action = actionEdit
+	req.ReplyToID = 0
+	h.handlePrivateRequest(ctx, b, msg.Chat.ID, user, req)
+}
+
+// messageRequest builds the request for a text, photo or document message.
+func (h *Handler) messageRequest(ctx context.Context, b *bot.Bot, user *domain.User, msg *models.Message) (privateRequest, bool) {
+	// Collect attached images and files
+	var fileURLs []string
+	if msg.Photo != nil && len(msg.Photo) > 0 {
+		// Get highest resolution photo
+		photo := msg.Photo[len(msg.Photo)-1]
+		url, err := tg.GetFileURL(ctx, b, photo.FileID)
+		if err == nil {
+			fileURLs = append(fileURLs, url)
+		}
+	}
+	var document *privateDocument
+	if msg.Document != nil {
+		if service.IsImageDocument(msg.Document.FileName, msg.Document.MimeType) {
+			url, err := tg.GetFileURL(ctx, b, msg.Document.FileID)
+			if err == nil {
+				fileURLs = append(fileURLs, url)
+			}
+		} else {
+			var ok bool
+			document, ok = h.loadDocument(ctx, b, msg.Chat.ID, msg.Document)
+			if !ok {
+				return privateRequest{}, false
+			}
+		}
+	}
 
+	userText := msg.Text
+	if msg.Caption != "" {
+		userText = msg.Caption
+	}
+
+	// "@alias question" sends the message to the aliased model
+	var modelOverride string
+	if modelID, rest, ok := h.aliasedModel(ctx, user, userText); ok && (rest != "" || len(fileURLs) > 0 || document != nil) {
+		modelOverride, userText = modelID, rest
+	}
+	if userText == "" && document == nil {
+		userText = "[File]"
+	}
+
+	req := privateRequest{
+		Text:      userText,
+		FileURLs:  fileURLs,
+		Document:  document,
+		MessageID: msg.ID,
+		Model:     modelOverride,
+	}
+	if reply := msg.ReplyToMessage; reply != nil && reply.From != nil && reply.From.IsBot {
+		req.ReplyToID = reply.ID
+	}
+	return req, true
+}
+
+// privateRequest is the user's input for one AI request in a private chat.
+type privateRequest struct {
+	Text      string   // prompt text, stored in the session
+	FileURLs  []string // attached images
+	Audio     *privateAudio
+	Document  *privateDocument
+	MessageID int    // the user's message, remembered so that it can be edited
+	ReplyToID int    // the bot's message it replies to, to branch the conversation there
+	Model     string // chosen with an alias for this message instead of the selected model
+
+	Transcript string // Audio as text, once transcribed
+	CostMode   string // how the user confirmed an expensive request, see costModeCapped
+
+	Action   string // actionRegenerate or actionContinue on AnswerID, or actionEdit
+	AnswerID int64
+	Accepted func() // called once the checks pass and the model is about to be asked
+}
+
+// privateAudio is a voice note or audio file sent instead of text.
+type privateAudio struct {
+	Data    []byte
+	Format  string // input_audio format, e.g. "ogg"
+	FileID  string
+	Name    string
+	Caption string
+}
+
+// balanceDenial returns why the user's balance does not allow a request to a
+// paid model, or "" if it does. Users without premium and with a low balance
+// cannot use expensive models.
+func balanceDenial(user *domain.User, model *domain.AIModel) string {
+	if model.IsFree() {
+		return ""
+	}
+	if user.Balance.LessThan(decimal.Zero) {
+		return "❌ Недостаточно средств. Пополните баланс: /pay"
+	}
+	if !user.IsPremium() {
+		avgPrice := (model.PromptPrice + model.CompletionPrice) / 2 / 1_000_000
+		if user.Balance.InexactFloat64() < config.LowBalanceThreshold && avgPrice > config.LowPriceThreshold {
+			return "❌ Баланс слишком мал для этой модели. Выберите бесплатную модель или пополните баланс."
+		}
+	}
+	return ""
+}
+
+// requestCooldown is how long a user waits between requests to the model.
+func requestCooldown(user *domain.User, model *domain.AIModel) time.Duration {
+	switch {
+	case user.IsPremium():
+		return config.CooldownPremium
+	case model.IsFree() && user.Balance.InexactFloat64() < config.LowBalanceThreshold:
+		return config.CooldownFree
+	}
+	return config.CooldownRegular
+}
+
+// handlePrivateRequest runs the checks, model call, billing and session
+// bookkeeping shared by all kinds of private messages.
+func (h *Handler) handlePrivateRequest(ctx context.Context, b *bot.Bot, chatID int64, user *domain.User, req privateRequest) {
 	// 1. Check active request
 	_, err := h.queries.TrySetActiveRequest(ctx, chatID)
 	if err != nil {
@@ -55,49 +203,43 @@ func (h *Handler) HandleTextPrivate(ctx context.Context, b *bot.Bot, update *mod
 	}
 	defer h.queries.RemoveActiveRequest(ctx, chatID)
 
-	// 2. Get model info
-	model, err := h.openRouter.GetModel(ctx, user.SelectedModel)
+	// 2. Get model info; an alias picks the model for this message only
+	modelID := user.SelectedModel
+	if req.Model != "" {
+		modelID = req.Model
+	}
+	model, err := h.llm.GetModel(ctx, modelID)
 	if err != nil {
-		slog.Error("get model", "error", err, "model", user.SelectedModel)
+		slog.Error("get model", "error", err, "model", modelID)
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: chatID,
 			Text:   "❌ Модель не найдена. Используйте /models для выбора.",
 		})
 		return
 	}
+	if len(req.FileURLs) > 0 && !model.Capabilities.Vision {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Эта модель не принимает изображения. Выберите модель с 👁 в /models.",
+		})
+		return
+	}
 
 	// 3. Check balance for paid models
-	if !model.IsFree() {
-		if user.Balance.LessThan(decimal.Zero) {
-			b.SendMessage(ctx, &bot.SendMessageParams{
-				ChatID: chatID,
-				Text:   "❌ Недостаточно средств. Пополните баланс: /pay",
-			})
-			return
-		}
-		// Low balance + expensive model check
-		if !user.IsPremium() {
-			avgPrice := (model.PromptPrice + model.CompletionPrice) / 2 / 1_000_000
-			if user.Balance.InexactFloat64() < config.LowBalanceThreshold && avgPrice > config.LowPriceThreshold {
-				b.SendMessage(ctx, &bot.SendMessageParams{
-					ChatID: chatID,
-					Text:   "❌ Баланс слишком мал для этой модели. Выберите бесплатную модель или пополните баланс.",
-				})
-				return
-			}
-		}
+	if text := balanceDenial(user, model); text != "" {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   text,
+		})
+		return
 	}
 
 	// 4. Check cooldown
-	cooldown := config.CooldownRegular
-	if user.IsPremium() {
-		cooldown = config.CooldownPremium
-	} else if model.IsFree() && user.Balance.InexactFloat64() < config.LowBalanceThreshold {
-		cooldown = config.CooldownFree
-	}
+	cooldown := requestCooldown(user, model)
 
+	// A confirmed request already waited out the cooldown when it was asked
 	timeSinceLast := time.Since(user.LastInteraction)
-	if timeSinceLast < cooldown {
+	if timeSinceLast < cooldown && req.CostMode == "" {
 		remaining := cooldown - timeSinceLast
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: chatID,
@@ -110,27 +252,44 @@ func (h *Handler) HandleTextPrivate(ctx context.Context, b *bot.Bot, update *mod
 	h.userService.UpdateLastInteraction(ctx, user.ID)
 
 	// 6. Handle session
-	// Auto-reset if timeout
-	if h.sessionService.IsExpired(user) {
-		h.sessionService.Reset(ctx, user)
-	}
-	// Auto-reset if context disabled
-	if !user.ContextEnabled {
-		h.sessionService.Reset(ctx, user)
+	// A reply to an older answer branches the conversation at that answer
+	var session *domain.ChatSession
+	if req.ReplyToID != 0 && req.Action == "" && user.ContextEnabled {
+		session, err = h.sessionService.ForkAt(ctx, user, int64(req.ReplyToID))
+		if err != nil {
+			slog.Error("fork session", "error", err)
+		}
+		if session != nil {
+			// Already forked if the request waits for cost confirmation
+			req.ReplyToID = 0
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "🌿 Новая ветка диалога: продолжаю с выбранного ответа. Все диалоги: /sessions",
+			})
+		}
 	}
 
-	session, err := h.sessionService.FindOrCreate(ctx, user)
-	if err != nil {
-		slog.Error("find or create session", "error", err)
-		b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID: chatID,
-			Text:   "❌ Ошибка при создании сессии.",
-		})
-		return
+	if session == nil {
+		// Auto-reset if timeout or context disabled; actions on an answer
+		// need the session it was given in
+		if req.Action == "" && (h.sessionService.IsExpired(user) || !user.ContextEnabled) {
+			h.sessionService.Reset(ctx, user)
+		}
+
+		session, err = h.sessionService.FindOrCreate(ctx, user)
+		if err != nil {
+			slog.Error("find or create session", "error", err)
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "❌ Ошибка при создании сессии.",
+			})
+			return
+		}
 	}
 
-	// Update session model if different
-	if session.Model != user.SelectedModel {
+	// Update session model if different, unless an alias chose another
+	// model for this message
+	if req.Model == "" && session.Model != user.SelectedModel {
 		h.queries.UpdateSessionModel(ctx, sqlc.UpdateSessionModelParams{
 			ID:    session.ID,
 			Model: user.SelectedModel,
@@ -150,7 +309,9 @@ func (h *Handler) HandleTextPrivate(ctx context.Context, b *bot.Bot, update *mod
 		return
 	}
 
-	if msgCount >= int64(maxMessages) {
+	// Regenerating or continuing an answer adds no messages
+	if req.Action == "" && msgCount >= int64(maxMessages) && !h.summarizeSession(ctx, b, chatID, user, session, maxMessages) {
+		// Summarization failed: start over as a last resort
 		session, err = h.sessionService.Reset(ctx, user)
 		if err != nil {
 			slog.Error("reset session on limit", "error", err)
@@ -162,21 +323,30 @@ func (h *Handler) HandleTextPrivate(ctx context.Context, b *bot.Bot, update *mod
 		})
 	}
 
-	// 8. Process files/images
-	var fileURLs []string
-	if msg.Photo != nil && len(msg.Photo) > 0 {
-		// Get highest resolution photo
-		photo := msg.Photo[len(msg.Photo)-1]
-		url, err := tg.GetFileURL(ctx, b, photo.FileID)
-		if err == nil {
-			fileURLs = append(fileURLs, url)
+	// 8. Transcribe voice input if the model cannot listen
+	userText := req.Text
+	if req.Audio != nil && !model.Capabilities.Audio {
+		if req.Transcript == "" {
+			transcript, ok := h.transcribeVoice(ctx, b, chatID, user, req.Audio)
+			if !ok {
+				return
+			}
+			req.Transcript = transcript
 		}
+		userText = req.Transcript
 	}
-	if msg.Document != nil {
-		url, err := tg.GetFileURL(ctx, b, msg.Document.FileID)
-		if err == nil {
-			fileURLs = append(fileURLs, url)
+
+	// Inline the document text; a PDF without a text layer can only be read
+	// by OpenRouter, which parses the file itself
+	if req.Document != nil {
+		if req.Document.PDF != nil && model.Provider != service.ProviderOpenRouter {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "❌ В PDF нет текстового слоя (возможно, это скан). Отправьте страницы как фото или выберите модель OpenRouter.",
+			})
+			return
 		}
+		userText = req.Document.Prompt(userText)
 	}
 
 	// 9. Build messages for AI
@@ -186,124 +356,317 @@ func (h *Handler) HandleTextPrivate(ctx context.Context, b *bot.Bot, update *mod
 		return
 	}
 
-	var chatMessages []service.ChatMessage
-	for _, m := range history {
-		chatMessages = append(chatMessages, service.ChatMessage{
-			Role:    m.Role,
-			Content: m.Text,
-		})
-	}
-
-	// Add current user message
-	userText := msg.Text
-	if msg.Caption != "" {
-		userText = msg.Caption
-	}
-	if userText == "" {
-		userText = "[File]"
+	// A regenerated answer is dropped and its prompt sent again; a continued
+	// answer is sent as it is, followed by the request to go on; an edited
+	// prompt replaces the old one together with its answer
+	var replacedIDs []int64
+	var continued *domain.SessionMessage
+	var editedAnswer []int64 // chat messages of the answer to an edited prompt
+	if req.Action != "" {
+		answerID := req.AnswerID
+		if req.Action == actionEdit && len(history) > 0 {
+			answerID = history[len(history)-1].ID
+		}
+		promptIdx, ok := lastAnswer(history, answerID)
+		if req.Action == actionEdit {
+			if !ok || !slices.Contains(history[promptIdx].TelegramMessageIDs, int64(req.MessageID)) {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: chatID,
+					Text:   "✏️ Заново отправляется только последний запрос текущего диалога. Чтобы спросить снова, отправьте новое сообщение.",
+				})
+				return
+			}
+		} else if !ok {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   "⌛️ Это можно сделать только с последним ответом текущего диалога.",
+			})
+			return
+		}
+		switch req.Action {
+		case actionRegenerate:
+			prompt := history[promptIdx]
+			if len(prompt.Images) > 0 && !model.Capabilities.Vision {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: chatID,
+					Text:   "❌ Эта модель не принимает изображения. Выберите модель с 👁 в /models.",
+				})
+				return
+			}
+			// The stored text already holds transcripts and document text;
+			// recordings and scanned PDFs are sent again from Telegram
+			audio, document, ok := h.replayFiles(ctx, b, chatID, model, prompt)
+			if !ok {
+				return
+			}
+			userText = prompt.Text
+			req.FileURLs = prompt.Images
+			req.Audio, req.Document = audio, document
+			for _, m := range history[promptIdx+1:] {
+				replacedIDs = append(replacedIDs, m.ID)
+			}
+			history = history[:promptIdx]
+		case actionContinue:
+			answer := history[len(history)-1]
+			continued = &answer
+			userText = continuePrompt
+		case actionEdit:
+			for _, m := range history[promptIdx:] {
+				replacedIDs = append(replacedIDs, m.ID)
+			}
+			editedAnswer = history[len(history)-1].TelegramMessageIDs
+			history = history[:promptIdx]
+		}
 	}
 
-	// Build content with images if present
+	// Build content with images, audio or a PDF file if present
 	var userContent interface{} = userText
-	if len(fileURLs) > 0 {
+	attachPDF := req.Document != nil && req.Document.PDF != nil
+	if len(req.FileURLs) > 0 || (req.Audio != nil && model.Capabilities.Audio) || attachPDF {
 		parts := []interface{}{
 			map[string]interface{}{"type": "text", "text": userText},
 		}
-		for _, url := range fileURLs {
+		for _, url := range req.FileURLs {
 			parts = append(parts, map[string]interface{}{
-				"type": "image_url",
+				"type":      "image_url",
 				"image_url": map[string]string{"url": url},
 			})
 		}
+		if req.Audio != nil && model.Capabilities.Audio {
+			parts = append(parts, service.AudioContentPart(req.Audio.Data, req.Audio.Format))
+		}
+		if attachPDF {
+			parts = append(parts, req.Document.FileContentPart())
+		}
 		userContent = parts
 	}
 
+	// Leave out the oldest turns that do not fit into the model's context
+	var droppedMessages int
+	if budget := service.ContextBudget(model); budget > 0 {
+		budget -= service.EstimateContentTokens(userContent)
+		if budget <= 0 {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   fmt.Sprintf("❌ Запрос слишком длинный для модели %s: её контекст — %d токенов. Сократите текст или выберите модель с большим контекстом: /models", model.ID, model.ContextLength),
+			})
+			return
+		}
+		history, droppedMessages = service.FitHistory(history, budget)
+	}
+
+	chatMessages := service.SessionChatMessages(history, model.Capabilities.Tools)
 	chatMessages = append(chatMessages, service.ChatMessage{
 		Role:    "user",
 		Content: userContent,
 	})
 
-	// 10. Send typing indicator (repeats every 4s until stopped)
+	markupPercent := h.cfg.MarkupPercentNormal
+	if user.IsPremium() {
+		markupPercent = h.cfg.MarkupPercentPremium
+	}
+
+	// 10. Estimate the cost and keep the request within the user's limit
+	var maxTokens *int
+	if !model.IsFree() {
+		estimate := service.EstimateCost(model, chatMessages, len(req.FileURLs), markupPercent)
+		if session.Params.MaxTokens != nil && model.Capabilities.MaxTokens {
+			estimate = estimate.LimitCompletion(*session.Params.MaxTokens)
+		}
+		if estimate.Min.GreaterThan(user.Balance) {
+			b.SendMessage(ctx, &bot.SendMessageParams{
+				ChatID: chatID,
+				Text:   fmt.Sprintf("❌ Запрос обойдётся минимум в $%.4f, а на балансе $%.4f. Пополните баланс: /pay", estimate.Min.InexactFloat64(), user.Balance.InexactFloat64()),
+			})
+			return
+		}
+		if user.MaxRequestCost.IsPositive() && estimate.Max.GreaterThan(user.MaxRequestCost) {
+			// The answer can only be cut to the limit through max_tokens
+			if !model.Capabilities.MaxTokens {
+				b.SendMessage(ctx, &bot.SendMessageParams{
+					ChatID: chatID,
+					Text:   fmt.Sprintf("❌ Запрос может стоить до $%.4f, больше вашего лимита, а длину ответа этой модели ограничить нельзя. Выберите другую модель или увеличьте лимит в /settings.", estimate.Max.InexactFloat64()),
+				})
+				return
+			}
+			tokens := estimate.TokensWithin(user.MaxRequestCost)
+			switch req.CostMode {
+			case "":
+				h.askCostConfirmation(ctx, b, chatID, user, req, estimate, tokens)
+				return
+			case costModeCapped:
+				if tokens < config.MinCappedCompletionTokens {
+					b.SendMessage(ctx, &bot.SendMessageParams{
+						ChatID: chatID,
+						Text:   "❌ Запрос не укладывается в лимит стоимости. Начните новый диалог (/end) или увеличьте лимит в /settings.",
+					})
+					return
+				}
+				maxTokens = &tokens
+			case costModeFull:
+				// The answer is still kept within the balance
+				full := estimate.TokensWithin(user.Balance)
+				if full <= 0 {
+					b.SendMessage(ctx, &bot.SendMessageParams{
+						ChatID: chatID,
+						Text:   "❌ На балансе не хватает средств на ответ. Пополните баланс: /pay",
+					})
+					return
+				}
+				maxTokens = &full
+			}
+		}
+	}
+
+	if req.Accepted != nil {
+		req.Accepted()
+	}
+
+	// 11. Send typing indicator (repeats every 4s until stopped)
 	stopTyping := tg.StartTyping(ctx, b, chatID)
 	defer stopTyping()
 
 	statusText := "⏳ Обрабатываю запрос..."
+	if req.Model != "" {
+		statusText = fmt.Sprintf("⏳ Обрабатываю запрос моделью %s...", model.ID)
+	}
 	if model.IsFree() {
-		statusText = "⏳ Обрабатываю запрос...\n\n⚠️ Бесплатная модель — ответ может занять больше времени."
+		statusText += "\n\n⚠️ Бесплатная модель — ответ может занять больше времени."
+	}
+	// The answer to an edited prompt is rewritten in its first message
+	var statusMsg *models.Message
+	if len(editedAnswer) > 0 {
+		statusMsg, _ = b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:      chatID,
+			MessageID:   int(editedAnswer[0]),
+			Text:        statusText,
+			ReplyMarkup: cancelKeyboard(),
+		})
+		if statusMsg != nil {
+			for _, id := range editedAnswer[1:] {
+				b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: int(id)})
+			}
+		}
+	}
+	if statusMsg == nil {
+		statusMsg, _ = b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:      chatID,
+			Text:        statusText,
+			ReplyMarkup: cancelKeyboard(),
+		})
 	}
-	statusMsg, _ := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: chatID,
-		Text:   statusText,
-	})
 
-	// 11. Call OpenRouter API
+	// 12. Call the model, streaming the answer into the status message
 	reqCtx, cancel := context.WithTimeout(ctx, config.RequestTimeout)
 	defer cancel()
+	h.inFlight.Put(chatID, cancel)
+	defer h.inFlight.Take(chatID)
 
-	var temperature *float64
-	temp := user.Temperature
-	temperature = &temp
+	temperature := session.Temperature
 
-	aiResp, err := h.openRouter.Chat(reqCtx, chatMessages, user.SelectedModel, temperature)
-	if err != nil {
-		slog.Error("openrouter chat", "error", err)
-		errText := "❌ Ошибка при обработке запроса."
-		if strings.Contains(err.Error(), "429") {
-			errText = "⏳ Слишком много запросов к AI. Попробуйте позже."
-		} else if strings.Contains(err.Error(), "503") {
-			errText = "❌ Сервис AI временно недоступен."
-		} else if reqCtx.Err() != nil {
-			errText = "⏳ Превышено время ожидания ответа."
+	// Voice replies are sent once synthesized, so the text is not streamed
+	voiceReply := user.VoiceReplies && h.tts.Enabled() && (h.tts.IsFree() || user.Balance.IsPositive())
+
+	var stream *tg.MessageStream
+	var onDelta func(string)
+	if statusMsg != nil && !voiceReply {
+		stream = tg.NewMessageStream(b, chatID, statusMsg.ID, config.StreamEditInterval)
+		stream.SetReplyMarkup(cancelKeyboard())
+		onDelta = func(delta string) { stream.Append(ctx, delta) }
+	}
+
+	chatReq := service.ChatRequest{
+		Model:       model.ID,
+		Messages:    chatMessages,
+		Temperature: &temperature,
+	}
+	chatReq = service.ApplySessionParams(chatReq, session.Params)
+	// The cost limit only shortens the answer further than the session does
+	if maxTokens != nil && (chatReq.MaxTokens == nil || *maxTokens < *chatReq.MaxTokens) {
+		chatReq.MaxTokens = maxTokens
+	}
+	if model.Capabilities.ImageGeneration {
+		chatReq.Modalities = []string{"image", "text"}
+	}
+	// Reasoning text is only requested when it will be shown
+	if model.Capabilities.Reasoning && (user.ReasoningEffort != "" || !user.ShowReasoning) {
+		chatReq.Reasoning = &service.ReasoningConfig{
+			Effort:  user.ReasoningEffort,
+			Exclude: !user.ShowReasoning,
 		}
-		if statusMsg != nil {
-			b.EditMessageText(ctx, &bot.EditMessageTextParams{
-				ChatID:    chatID,
-				MessageID: statusMsg.ID,
-				Text:      errText,
-			})
+	}
+	chat := func(ctx context.Context, req service.ChatRequest) (*service.ChatResponse, *domain.AIModel, error) {
+		return h.fallbackService.ChatStream(ctx, req, onDelta)
+	}
+
+	// Tool-capable models may call server-side tools before answering
+	var aiResp *service.ChatResponse
+	var usedModel *domain.AIModel
+	var toolMessages []service.ChatMessage
+	if model.Capabilities.Tools && continued == nil {
+		onToolCall := func(call service.ToolCall) {
+			// Only the final round's text is stored, so text written before
+			// the tool calls is not kept on screen either
+			if stream != nil {
+				stream.Reset(ctx)
+				b.EditMessageText(ctx, &bot.EditMessageTextParams{
+					ChatID:      chatID,
+					MessageID:   stream.LastMessageID(),
+					Text:        fmt.Sprintf("🔧 Использую инструмент: %s...", call.Function.Name),
+					ReplyMarkup: cancelKeyboard(),
+				})
+			}
 		}
+		aiResp, usedModel, toolMessages, err = h.toolRegistry.RunWithTools(reqCtx, user, chatReq, chat, onToolCall)
+	} else {
+		aiResp, usedModel, err = chat(reqCtx, chatReq)
+	}
+	// Too late to cancel: the answer is charged and saved from here on
+	h.inFlight.Take(chatID)
+	if err != nil {
+		slog.Error("llm chat", "error", err)
+		h.failStream(ctx, b, chatID, stream, statusMsg, h.llmErrorText(reqCtx, err))
 		return
 	}
 
-	if len(aiResp.Choices) == 0 {
-		if statusMsg != nil {
-			b.EditMessageText(ctx, &bot.EditMessageTextParams{
-				ChatID:    chatID,
-				MessageID: statusMsg.ID,
-				Text:      "❌ AI не вернул ответ.",
-			})
-		}
+	images := aiResp.ImageURLs()
+	if len(aiResp.Choices) == 0 || (aiResp.Choices[0].Message.Content == "" && len(images) == 0) {
+		h.failStream(ctx, b, chatID, stream, statusMsg, "❌ AI не вернул ответ.")
 		return
 	}
 
 	responseText := aiResp.Choices[0].Message.Content
 
-	// 12. Calculate cost
-	markupPercent := h.cfg.MarkupPercentNormal
-	if user.IsPremium() {
-		markupPercent = h.cfg.MarkupPercentPremium
+	// Bill at the model that actually answered
+	fallbackUsed := usedModel.ID != model.ID
+	model = usedModel
+
+	// 13. Calculate cost
+	// Synthesize the voice reply first so its cost goes into the same transaction
+	var voiceClips [][]byte
+	ttsCost := decimal.Zero
+	if voiceReply && responseText != "" {
+		clips, chars, err := h.tts.Synthesize(reqCtx, responseText)
+		if err != nil {
+			slog.Error("synthesize voice reply", "error", err)
+		} else {
+			voiceClips = clips
+			ttsCost = h.tts.Cost(chars, markupPercent)
+		}
 	}
 
 	totalCost := decimal.Zero
 	var newBalance decimal.Decimal
 
-	if !model.IsFree() {
-		totalCost = service.CalculateCost(
-			aiResp.Usage.PromptTokens,
-			aiResp.Usage.CompletionTokens,
-			model.PromptPrice,
-			model.CompletionPrice,
-			markupPercent,
-		)
+	if !model.IsFree() || ttsCost.IsPositive() {
+		totalCost = service.AnswerCost(aiResp.Usage, model, len(images), len(req.FileURLs), markupPercent).Add(ttsCost)
 
-		// Use API-provided total_cost if available
-		if aiResp.Usage.TotalCost > 0 {
-			baseCost := decimal.NewFromFloat(aiResp.Usage.TotalCost)
-			markup := decimal.NewFromFloat(1 + markupPercent/100)
-			totalCost = baseCost.Mul(markup)
+		description := fmt.Sprintf("AI request: %s", model.ID)
+		if ttsCost.IsPositive() {
+			description += " + TTS"
 		}
 
-		// 13. Process transaction
+		// 14. Process transaction
 		negCost := totalCost.Neg()
 		newBalance, err = h.queries.UpdateUserBalanceWithCheck(ctx, sqlc.UpdateUserBalanceWithCheckParams{
 			ID:      user.ID,
@@ -326,27 +689,131 @@ func (h *Handler) HandleTextPrivate(ctx context.Context, b *bot.Bot, update *mod
 			UserID:      &user.ID,
 			Amount:      negCost,
 			TxType:      string(domain.TxTypeDebit),
-			Description: fmt.Sprintf("AI request: %s", user.SelectedModel),
+			Description: description,
+		})
+		h.trackCharge(ctx, service.GenerationCharge{
+			UserID:        &user.ID,
+			Model:         model,
+			Usage:         aiResp.Usage,
+			Charged:       totalCost.Sub(ttsCost),
+			MarkupPercent: markupPercent,
 		})
 	}
 
-	// 14. Save messages to session
-	h.sessionService.AddMessage(ctx, session.ID, "user", userText, fileURLs, false)
-	h.sessionService.AddMessage(ctx, session.ID, "assistant", responseText, nil, false)
+	// 15. Save messages to session. A regenerated answer or an edited prompt
+	// replaces the old messages, a continuation is added to the answer it
+	// continues
+	if err := h.sessionService.DeleteMessages(ctx, session.ID, replacedIDs); err != nil {
+		slog.Error("delete replaced messages", "error", err)
+	}
+	if req.Action == "" || req.Action == actionEdit {
+		userMsg, err := h.sessionService.AddMessage(ctx, session.ID, "user", userText, req.FileURLs, false)
+		if err != nil {
+			slog.Error("save user message", "error", err)
+		} else if req.Audio != nil {
+			if err := h.sessionService.AddMessageFile(ctx, userMsg.ID, "audio", req.Audio.FileID, req.Audio.Name); err != nil {
+				slog.Error("save voice message", "error", err)
+			}
+		} else if req.Document != nil {
+			if err := h.sessionService.AddMessageFile(ctx, userMsg.ID, "document", req.Document.FileID, req.Document.Name); err != nil {
+				slog.Error("save document", "error", err)
+			}
+		}
+		if userMsg != nil && req.MessageID != 0 {
+			if err := h.sessionService.SetTelegramMessageIDs(ctx, userMsg.ID, []int64{int64(req.MessageID)}); err != nil {
+				slog.Error("save prompt message id", "error", err)
+			}
+		}
+	}
+	if err := h.sessionService.AddToolMessages(ctx, session.ID, toolMessages); err != nil {
+		slog.Error("save tool messages", "error", err)
+	}
+	savedText := responseText
+	if savedText == "" {
+		savedText = "[generated image]"
+	}
+	var assistantMsg *domain.SessionMessage
+	if continued != nil {
+		assistantMsg = continued
+		assistantMsg.Text += responseText
+		err = h.sessionService.UpdateMessageText(ctx, assistantMsg.ID, assistantMsg.Text)
+	} else {
+		assistantMsg, err = h.sessionService.AddMessage(ctx, session.ID, "assistant", savedText, nil, false)
+	}
+	if err != nil {
+		slog.Error("save assistant message", "error", err)
+	}
+
+	// 16. Render the final answer
+	if voiceReply && statusMsg != nil {
+		b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: statusMsg.ID})
+	}
+	if len(voiceClips) > 0 && !h.sendVoiceReply(ctx, b, chatID, voiceClips, assistantMsg) {
+		voiceClips = nil
+	}
+
+	switch {
+	case len(voiceClips) > 0:
+		// Already sent as voice
+	case stream != nil && responseText == "":
+		// Image-only answer: the status message has nothing to show
+		b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: chatID, MessageID: stream.LastMessageID()})
+	case stream != nil:
+		if err := stream.Finish(ctx); err != nil {
+			slog.Error("finish stream", "error", err)
+		}
+		if assistantMsg != nil {
+			h.linkAnswer(ctx, b, chatID, assistantMsg, stream.MessageIDs(), aiResp.Choices[0].FinishReason == "length")
+		}
+	case responseText != "":
+		tg.SendLongMessage(ctx, b, chatID, responseText, nil)
+	}
+
+	if len(images) > 0 {
+		fileIDs, err := tg.SendImages(ctx, b, chatID, images)
+		if err != nil {
+			slog.Error("send generated images", "error", err)
+		}
+		// Keep the Telegram copies: data URLs are too large to store
+		for i, fileID := range fileIDs {
+			if assistantMsg == nil || fileID == "" {
+				continue
+			}
+			if err := h.sessionService.AddMessageFile(ctx, assistantMsg.ID, "image", fileID, fmt.Sprintf("image%d.png", i+1)); err != nil {
+				slog.Error("save generated image", "error", err)
+			}
+		}
+	}
+
+	// Show the reasoning collapsed, as a reply to the answer it led to
+	if reasoning := strings.TrimSpace(aiResp.ReasoningText()); user.ShowReasoning && reasoning != "" {
+		var replyToID *int
+		if stream != nil && responseText != "" && len(voiceClips) == 0 {
+			id := stream.MessageIDs()[0]
+			replyToID = &id
+		}
+		if err := tg.SendExpandableQuote(ctx, b, chatID, "🧠 Рассуждения модели", reasoning, replyToID); err != nil {
+			slog.Error("send reasoning", "error", err)
+		}
+	}
 
-	// 15. Delete status message
-	if statusMsg != nil {
-		b.DeleteMessage(ctx, &bot.DeleteMessageParams{
+	if fallbackUsed {
+		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID:    chatID,
-			MessageID: statusMsg.ID,
+			Text:      fmt.Sprintf("↪️ Основная модель недоступна, ответила `%s`.", model.ID),
+			ParseMode: models.ParseModeMarkdownV1,
 		})
 	}
 
-	// 16. Send response
-	tg.SendLongMessage(ctx, b, chatID, responseText, nil)
+	if droppedMessages > 0 {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("✂️ Диалог не помещается в контекст модели: %d старых сообщений не были отправлены. Начать новый диалог: /end", droppedMessages),
+		})
+	}
 
 	// 17. Show cost if enabled
-	if user.ShowCost && !model.IsFree() {
+	if user.ShowCost && (!model.IsFree() || ttsCost.IsPositive()) {
 		costText := fmt.Sprintf(
 			"💰 Стоимость: $%.6f | Баланс: $%.4f\n📊 Токены: %d→%d",
 			totalCost.InexactFloat64(),
@@ -354,9 +821,78 @@ func (h *Handler) HandleTextPrivate(ctx context.Context, b *bot.Bot, update *mod
 			aiResp.Usage.PromptTokens,
 			aiResp.Usage.CompletionTokens,
 		)
+		if reasoningTokens := aiResp.Usage.CompletionTokensDetails.ReasoningTokens; reasoningTokens > 0 {
+			costText += fmt.Sprintf(" (🧠 рассуждения: %d)", reasoningTokens)
+		}
+		if cached := aiResp.Usage.PromptTokensDetails.CachedTokens; cached > 0 {
+			costText += fmt.Sprintf("\n💾 Из кэша: %d из %d токенов запроса", cached, aiResp.Usage.PromptTokens)
+		}
 		b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: chatID,
 			Text:   costText,
 		})
 	}
 }
+
+// llmErrorText explains a failed AI request to the user. Errors caused by our
+// own provider account are also reported to the admin log.
+func (h *Handler) llmErrorText(reqCtx context.Context, err error) string {
+	var upErr *service.UpstreamError
+	if errors.As(err, &upErr) {
+		switch upErr.Kind {
+		case service.UpstreamRateLimit:
+			return "⏳ Слишком много запросов к AI. Попробуйте позже."
+		case service.UpstreamOverloaded:
+			return "❌ Сервис AI временно недоступен."
+		case service.UpstreamContextLength:
+			return "📏 Диалог слишком длинный для этой модели. Завершите сессию: /end"
+		case service.UpstreamModeration:
+			return "🚫 Запрос отклонён модерацией провайдера."
+		case service.UpstreamAuth:
+			h.tgLogger.LogError(err, "LLM provider rejected credentials")
+			return "❌ Сервис AI временно недоступен."
+		}
+	}
+	switch {
+	case errors.Is(err, domain.ErrCircuitOpen):
+		return "⚠️ Модель временно отключена из-за сбоев. Попробуйте позже или выберите другую: /models"
+	case errors.Is(err, domain.ErrRateLimited):
+		return "⏳ Слишком много запросов к AI. Попробуйте позже."
+	case errors.Is(err, domain.ErrServiceUnavailable):
+		return "❌ Сервис AI временно недоступен."
+	case errors.Is(reqCtx.Err(), context.Canceled):
+		return "✖️ Запрос отменён."
+	case reqCtx.Err() != nil:
+		return "⏳ Превышено время ожидания ответа."
+	}
+	return "❌ Ошибка при обработке запроса."
+}
+
+// failStream reports a failed request: the status message is replaced with the
+// error, or the error is sent separately if part of the answer was already shown.
+// Without a stream, as for voice replies, statusMsg is the status message.
+func (h *Handler) failStream(ctx context.Context, b *bot.Bot, chatID int64, stream *tg.MessageStream, statusMsg *models.Message, errText string) {
+	if stream == nil && statusMsg != nil {
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: statusMsg.ID,
+			Text:      errText,
+		})
+		return
+	}
+	if stream != nil && stream.Len() == 0 {
+		b.EditMessageText(ctx, &bot.EditMessageTextParams{
+			ChatID:    chatID,
+			MessageID: stream.LastMessageID(),
+			Text:      errText,
+		})
+		return
+	}
+	if stream != nil {
+		stream.Finish(ctx)
+	}
+	b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   errText,
+	})
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func TestRequestCooldown(t *testing.T) {
+	premiumUntil := time.Now().Add(time.Hour)
+	free := &domain.AIModel{}
+	paid := &domain.AIModel{PromptPrice: 1, CompletionPrice: 2}
+
+	tests := []struct {
+		name  string
+		user  *domain.User
+		model *domain.AIModel
+		want  time.Duration
+	}{
+		{"premium", &domain.User{PremiumUntil: &premiumUntil}, free, config.CooldownPremium},
+		{"free model on a low balance", &domain.User{}, free, config.CooldownFree},
+		{"free model with a balance", &domain.User{Balance: decimal.NewFromInt(5)}, free, config.CooldownRegular},
+		{"paid model", &domain.User{}, paid, config.CooldownRegular},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requestCooldown(tt.user, tt.model); got != tt.want {
+				t.Errorf("requestCooldown() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBalanceDenial(t *testing.T) {
+	premiumUntil := time.Now().Add(time.Hour)
+	cheap := &domain.AIModel{PromptPrice: 0.1, CompletionPrice: 0.2}
+	expensive := &domain.AIModel{PromptPrice: 1_000_000, CompletionPrice: 1_000_000}
+
+	tests := []struct {
+		name   string
+		user   *domain.User
+		model  *domain.AIModel
+		denied bool
+	}{
+		{"free model with a debt", &domain.User{Balance: decimal.NewFromInt(-1)}, &domain.AIModel{}, false},
+		{"paid model with a debt", &domain.User{Balance: decimal.NewFromInt(-1)}, cheap, true},
+		{"cheap model on a low balance", &domain.User{}, cheap, false},
+		{"expensive model on a low balance", &domain.User{}, expensive, true},
+		{"expensive model for premium", &domain.User{PremiumUntil: &premiumUntil}, expensive, false},
+		{"expensive model with a balance", &domain.User{Balance: decimal.NewFromInt(10)}, expensive, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := balanceDenial(tt.user, tt.model); (got != "") != tt.denied {
+				t.Errorf("balanceDenial() = %q, want denied %v", got, tt.denied)
+			}
+		})
+	}
+}
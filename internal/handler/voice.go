@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/middleware"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+	"github.com/set-night/mindapp/internal/service"
+	tg "github.com/set-night/mindapp/internal/telegram"
+	"github.com/shopspring/decimal"
+)
+
+// HandleVoicePrivate processes voice notes, audio files and video notes in
+// private chats. Audio-capable models get the recording itself, other models
+// get a transcript.
+func (h *Handler) HandleVoicePrivate(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil || update.Message.Chat.Type != "private" {
+		return
+	}
+
+	msg := update.Message
+	user := middleware.GetUser(ctx)
+	if user == nil {
+		return
+	}
+
+	chatID := msg.Chat.ID
+
+	var fileID, name string
+	var duration int
+	var size int64
+	switch {
+	case msg.Voice != nil:
+		fileID, duration, size = msg.Voice.FileID, msg.Voice.Duration, msg.Voice.FileSize
+		name = "voice.ogg"
+	case msg.Audio != nil:
+		fileID, duration, size = msg.Audio.FileID, msg.Audio.Duration, msg.Audio.FileSize
+		name = msg.Audio.FileName
+		if name == "" {
+			name = "audio"
+		}
+	case msg.VideoNote != nil:
+		fileID, duration, size = msg.VideoNote.FileID, msg.VideoNote.Duration, int64(msg.VideoNote.FileSize)
+		name = "video_note.mp4"
+	default:
+		return
+	}
+
+	if time.Duration(duration)*time.Second > config.MaxVoiceDuration || size > config.MaxVoiceFileSize {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   fmt.Sprintf("❌ Слишком длинная запись. Максимум — %d минут.", int(config.MaxVoiceDuration.Minutes())),
+		})
+		return
+	}
+
+	data, filePath, err := tg.DownloadFile(ctx, b, fileID)
+	if err != nil {
+		slog.Error("download voice", "error", err)
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   "❌ Не удалось загрузить аудио.",
+		})
+		return
+	}
+
+	text := msg.Caption
+	if text == "" {
+		text = "[Voice message]"
+	}
+
+	h.handlePrivateRequest(ctx, b, chatID, user, privateRequest{
+		Text: text,
+		Audio: &privateAudio{
+			Data:    data,
+			Format:  service.AudioFormat(filePath),
+			FileID:  fileID,
+			Name:    name,
+			Caption: msg.Caption,
+		},
+	})
+}
+
+// transcribeVoice converts a recording to text with the speech-to-text model,
+// charges the user for it and echoes the transcript. It returns false if the
+// request should not continue; the user has already been told why.
+func (h *Handler) transcribeVoice(ctx context.Context, b *bot.Bot, chatID int64, user *domain.User, audio *privateAudio) (string, bool) {
+	statusMsg, _ := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   "🎤 Распознаю речь...",
+	})
+	report := func(text string) {
+		if statusMsg == nil {
+			b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text})
+			return
+		}
+		tg.EditLongMessage(ctx, b, chatID, statusMsg.ID, text, nil)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, config.RequestTimeout)
+	defer cancel()
+
+	transcript, usage, sttModel, err := h.transcription.Transcribe(reqCtx, audio.Data, audio.Format)
+	if err != nil {
+		slog.Error("transcribe voice", "error", err)
+		report("❌ Не удалось распознать голосовое сообщение.")
+		return "", false
+	}
+
+	// Charge for the transcription like a regular request
+	if !sttModel.IsFree() {
+		markupPercent := h.cfg.MarkupPercentNormal
+		if user.IsPremium() {
+			markupPercent = h.cfg.MarkupPercentPremium
+		}
+		cost := service.CalculateCost(usage, sttModel, markupPercent)
+		if usage.TotalCost > 0 {
+			cost = decimal.NewFromFloat(usage.TotalCost).Mul(decimal.NewFromFloat(1 + markupPercent/100))
+		}
+
+		negCost := cost.Neg()
+		if _, err := h.queries.UpdateUserBalanceWithCheck(ctx, sqlc.UpdateUserBalanceWithCheckParams{
+			ID:      user.ID,
+			Balance: negCost,
+		}); err != nil {
+			slog.Error("deduct transcription cost", "error", err)
+			report("❌ Недостаточно средств для распознавания речи.")
+			return "", false
+		}
+		h.queries.CreateTransaction(ctx, sqlc.CreateTransactionParams{
+			UserID:      &user.ID,
+			Amount:      negCost,
+			TxType:      string(domain.TxTypeDebit),
+			Description: fmt.Sprintf("Speech-to-text: %s", sttModel.ID),
+		})
+		h.trackCharge(ctx, service.GenerationCharge{
+			UserID:        &user.ID,
+			Model:         sttModel,
+			Usage:         usage,
+			Charged:       cost,
+			MarkupPercent: markupPercent,
+		})
+	}
+
+	if transcript == "" {
+		report("🤷 Речь в сообщении не найдена.")
+		return "", false
+	}
+
+	report("🎤 " + transcript)
+
+	if audio.Caption != "" {
+		transcript = audio.Caption + "\n\n" + transcript
+	}
+	return transcript, true
+}
+
+// sendVoiceReply sends a synthesized answer as voice messages, with a button
+// under the last one that reveals the text. It returns false if nothing was
+// sent, so the caller can fall back to text.
+func (h *Handler) sendVoiceReply(ctx context.Context, b *bot.Bot, chatID int64, clips [][]byte, answer *domain.SessionMessage) bool {
+	for i, clip := range clips {
+		params := &bot.SendVoiceParams{
+			ChatID: chatID,
+			Voice:  &models.InputFileUpload{Filename: "answer.ogg", Data: bytes.NewReader(clip)},
+		}
+		if i == len(clips)-1 && answer != nil {
+			params.ReplyMarkup = tg.InlineKeyboard(tg.ButtonRow(
+				tg.InlineButton("📝 Показать текст", fmt.Sprintf("show_text_%d", answer.ID)),
+			))
+		}
+		if _, err := b.SendVoice(ctx, params); err != nil {
+			slog.Error("send voice reply", "error", err)
+			return i > 0
+		}
+	}
+	return true
+}
+
+// handleShowText sends the text of an answer that was delivered as voice.
+func (h *Handler) handleShowText(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery == nil {
+		return
+	}
+	b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: update.CallbackQuery.ID})
+
+	user := middleware.GetUser(ctx)
+	msg := update.CallbackQuery.Message.Message
+	if user == nil || msg == nil {
+		return
+	}
+
+	messageID, err := strconv.ParseInt(strings.TrimPrefix(update.CallbackQuery.Data, "show_text_"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	answer, err := h.sessionService.GetUserMessage(ctx, user.ID, messageID)
+	if err != nil {
+		b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: msg.Chat.ID,
+			Text:   "❌ Текст ответа больше недоступен.",
+		})
+		return
+	}
+
+	// The button has done its job
+	b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+	})
+
+	replyToID := msg.ID
+	tg.SendLongMessage(ctx, b, msg.Chat.ID, answer.Text, &replyToID)
+}
@@ -14,14 +14,20 @@ import (
 func RateLimit(queries *sqlc.Queries) bot.Middleware {
 	return func(next bot.HandlerFunc) bot.HandlerFunc {
 		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
-			// Only rate limit messages (not callbacks or other updates)
-			if update.Message == nil {
+			// Only rate limit messages and inline queries (not callbacks or
+			// other updates). Inline queries count against the user's
+			// private chat, whose ID is the user's.
+			var chatID int64
+			switch {
+			case update.Message != nil:
+				chatID = update.Message.Chat.ID
+			case update.InlineQuery != nil && update.InlineQuery.From != nil:
+				chatID = update.InlineQuery.From.ID
+			default:
 				next(ctx, b, update)
 				return
 			}
 
-			chatID := update.Message.Chat.ID
-
 			// Check rate limit
 			count, err := queries.CheckAndIncrementRateLimit(ctx, chatID)
 			if err != nil {
@@ -39,6 +45,10 @@ func RateLimit(queries *sqlc.Queries) bot.Middleware {
 
 			if int64(count) > limit {
 				slog.Debug("rate limited", "chat_id", chatID, "count", count, "limit", limit)
+				if update.InlineQuery != nil {
+					// Left unanswered: the user may not have a chat with the bot
+					return
+				}
 				b.SendMessage(ctx, &bot.SendMessageParams{
 					ChatID: chatID,
 					Text:   "⏳ Слишком много запросов. Подождите немного.",
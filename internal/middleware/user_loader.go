@@ -50,6 +50,12 @@ func UserLoader(userService *service.UserService, groupService *service.GroupSer
 				chatID = update.Message.Chat.ID
 				chatUsername = update.Message.Chat.Username
 				chatTitle = update.Message.Chat.Title
+			} else if update.EditedMessage != nil {
+				from = update.EditedMessage.From
+				chatType = string(update.EditedMessage.Chat.Type)
+				chatID = update.EditedMessage.Chat.ID
+				chatUsername = update.EditedMessage.Chat.Username
+				chatTitle = update.EditedMessage.Chat.Title
 			} else if update.CallbackQuery != nil {
 				from = &update.CallbackQuery.From
 				if update.CallbackQuery.Message.Message != nil {
@@ -59,6 +65,8 @@ func UserLoader(userService *service.UserService, groupService *service.GroupSer
 					chatUsername = msg.Chat.Username
 					chatTitle = msg.Chat.Title
 				}
+			} else if update.InlineQuery != nil {
+				from = update.InlineQuery.From
 			} else if update.PreCheckoutQuery != nil {
 				from = update.PreCheckoutQuery.From
 			}
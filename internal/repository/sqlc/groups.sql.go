@@ -205,6 +205,42 @@ func (q *Queries) GetGroupForUpdate(ctx context.Context, id int64) (Group, error
 	return i, err
 }
 
+const replaceGroupModel = `-- name: ReplaceGroupModel :many
+UPDATE groups SET selected_model = $1::text, updated_at = NOW()
+WHERE selected_model = $2::text
+RETURNING telegram_id, thread_id
+`
+
+type ReplaceGroupModelParams struct {
+	NewModel string `json:"new_model"`
+	OldModel string `json:"old_model"`
+}
+
+type ReplaceGroupModelRow struct {
+	TelegramID int64  `json:"telegram_id"`
+	ThreadID   *int32 `json:"thread_id"`
+}
+
+func (q *Queries) ReplaceGroupModel(ctx context.Context, arg ReplaceGroupModelParams) ([]ReplaceGroupModelRow, error) {
+	rows, err := q.db.Query(ctx, replaceGroupModel, arg.NewModel, arg.OldModel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReplaceGroupModelRow{}
+	for rows.Next() {
+		var i ReplaceGroupModelRow
+		if err := rows.Scan(&i.TelegramID, &i.ThreadID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const setGroupPremiumUntil = `-- name: SetGroupPremiumUntil :exec
 UPDATE groups SET premium_until = $2, updated_at = NOW() WHERE id = $1
 `
@@ -14,13 +14,47 @@ type ActiveRequest struct {
 	StartedAt pgtype.Timestamptz `json:"started_at"`
 }
 
+type AiModel struct {
+	ID              string             `json:"id"`
+	Provider        string             `json:"provider"`
+	Data            []byte             `json:"data"`
+	PromptPrice     float64            `json:"prompt_price"`
+	CompletionPrice float64            `json:"completion_price"`
+	ImagePrice      float64            `json:"image_price"`
+	RequestPrice    float64            `json:"request_price"`
+	Available       bool               `json:"available"`
+	FirstSeenAt     pgtype.Timestamptz `json:"first_seen_at"`
+	LastSeenAt      pgtype.Timestamptz `json:"last_seen_at"`
+	RemovedAt       pgtype.Timestamptz `json:"removed_at"`
+}
+
 type ChatSession struct {
-	ID          int64              `json:"id"`
-	UserID      int64              `json:"user_id"`
-	Model       string             `json:"model"`
-	Temperature decimal.Decimal    `json:"temperature"`
-	CreatedAt   pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	ID              int64              `json:"id"`
+	UserID          int64              `json:"user_id"`
+	Model           string             `json:"model"`
+	Temperature     decimal.Decimal    `json:"temperature"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	Params          []byte             `json:"params"`
+	ParentSessionID *int64             `json:"parent_session_id"`
+	ForkMessageID   *int64             `json:"fork_message_id"`
+}
+
+type GenerationCost struct {
+	ID            int64              `json:"id"`
+	UserID        *int64             `json:"user_id"`
+	GroupID       *int64             `json:"group_id"`
+	Model         string             `json:"model"`
+	GenerationIds []string           `json:"generation_ids"`
+	MarkupPercent float64            `json:"markup_percent"`
+	EstimatedCost decimal.Decimal    `json:"estimated_cost"`
+	Charged       decimal.Decimal    `json:"charged"`
+	ActualCost    decimal.Decimal    `json:"actual_cost"`
+	Correction    decimal.Decimal    `json:"correction"`
+	Status        string             `json:"status"`
+	Attempts      int32              `json:"attempts"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	ReconciledAt  pgtype.Timestamptz `json:"reconciled_at"`
 }
 
 type Group struct {
@@ -64,6 +98,35 @@ type MessageFile struct {
 	Name      string `json:"name"`
 }
 
+type ModelAlias struct {
+	UserID    int64              `json:"user_id"`
+	Alias     string             `json:"alias"`
+	ModelID   string             `json:"model_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type ModelFallback struct {
+	ModelID        string             `json:"model_id"`
+	FallbackModels []string           `json:"fallback_models"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+type ModelPriceHistory struct {
+	ID              int64              `json:"id"`
+	ModelID         string             `json:"model_id"`
+	PromptPrice     float64            `json:"prompt_price"`
+	CompletionPrice float64            `json:"completion_price"`
+	ImagePrice      float64            `json:"image_price"`
+	RequestPrice    float64            `json:"request_price"`
+	ChangedAt       pgtype.Timestamptz `json:"changed_at"`
+}
+
+type ModelReplacement struct {
+	ModelID     string             `json:"model_id"`
+	Replacement string             `json:"replacement"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
 type PayTask struct {
 	ID           int64              `json:"id"`
 	Title        string             `json:"title"`
@@ -116,13 +179,18 @@ type RateLimit struct {
 }
 
 type SessionMessage struct {
-	ID        int64              `json:"id"`
-	SessionID int64              `json:"session_id"`
-	Role      string             `json:"role"`
-	Text      string             `json:"text"`
-	Images    []string           `json:"images"`
-	IsSystem  bool               `json:"is_system"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	ID           int64              `json:"id"`
+	SessionID    int64              `json:"session_id"`
+	Role         string             `json:"role"`
+	Text         string             `json:"text"`
+	Images       []string           `json:"images"`
+	IsSystem     bool               `json:"is_system"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	ToolCalls    []byte             `json:"tool_calls"`
+	ToolCallID   string             `json:"tool_call_id"`
+	Archived     bool               `json:"archived"`
+	IsSummary    bool               `json:"is_summary"`
+	TgMessageIds []int64            `json:"tg_message_ids"`
 }
 
 type Transaction struct {
@@ -158,4 +226,8 @@ type User struct {
 	LastSkysmart     pgtype.Timestamptz `json:"last_skysmart"`
 	CreatedAt        pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	VoiceReplies     bool               `json:"voice_replies"`
+	ShowReasoning    bool               `json:"show_reasoning"`
+	ReasoningEffort  string             `json:"reasoning_effort"`
+	MaxRequestCost   decimal.Decimal    `json:"max_request_cost"`
 }
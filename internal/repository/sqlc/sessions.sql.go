@@ -8,6 +8,7 @@ package sqlc
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
 )
 
@@ -35,7 +36,7 @@ func (q *Queries) AddMessageFile(ctx context.Context, arg AddMessageFileParams)
 const addSessionMessage = `-- name: AddSessionMessage :one
 INSERT INTO session_messages (session_id, role, text, images, is_system)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, session_id, role, text, images, is_system, created_at
+RETURNING id, session_id, role, text, images, is_system, created_at, tool_calls, tool_call_id, archived, is_summary, tg_message_ids
 `
 
 type AddSessionMessageParams struct {
@@ -63,12 +64,145 @@ func (q *Queries) AddSessionMessage(ctx context.Context, arg AddSessionMessagePa
 		&i.Images,
 		&i.IsSystem,
 		&i.CreatedAt,
+		&i.ToolCalls,
+		&i.ToolCallID,
+		&i.Archived,
+		&i.IsSummary,
+		&i.TgMessageIds,
 	)
 	return i, err
 }
 
+const addSummarySessionMessage = `-- name: AddSummarySessionMessage :one
+INSERT INTO session_messages (session_id, role, text, is_system, is_summary, created_at)
+VALUES ($1, $2, $3, TRUE, TRUE, $4)
+RETURNING id, session_id, role, text, images, is_system, created_at, tool_calls, tool_call_id, archived, is_summary, tg_message_ids
+`
+
+type AddSummarySessionMessageParams struct {
+	SessionID int64              `json:"session_id"`
+	Role      string             `json:"role"`
+	Text      string             `json:"text"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) AddSummarySessionMessage(ctx context.Context, arg AddSummarySessionMessageParams) (SessionMessage, error) {
+	row := q.db.QueryRow(ctx, addSummarySessionMessage,
+		arg.SessionID,
+		arg.Role,
+		arg.Text,
+		arg.CreatedAt,
+	)
+	var i SessionMessage
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Role,
+		&i.Text,
+		&i.Images,
+		&i.IsSystem,
+		&i.CreatedAt,
+		&i.ToolCalls,
+		&i.ToolCallID,
+		&i.Archived,
+		&i.IsSummary,
+		&i.TgMessageIds,
+	)
+	return i, err
+}
+
+const addToolSessionMessage = `-- name: AddToolSessionMessage :one
+INSERT INTO session_messages (session_id, role, text, tool_calls, tool_call_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, session_id, role, text, images, is_system, created_at, tool_calls, tool_call_id, archived, is_summary, tg_message_ids
+`
+
+type AddToolSessionMessageParams struct {
+	SessionID  int64  `json:"session_id"`
+	Role       string `json:"role"`
+	Text       string `json:"text"`
+	ToolCalls  []byte `json:"tool_calls"`
+	ToolCallID string `json:"tool_call_id"`
+}
+
+func (q *Queries) AddToolSessionMessage(ctx context.Context, arg AddToolSessionMessageParams) (SessionMessage, error) {
+	row := q.db.QueryRow(ctx, addToolSessionMessage,
+		arg.SessionID,
+		arg.Role,
+		arg.Text,
+		arg.ToolCalls,
+		arg.ToolCallID,
+	)
+	var i SessionMessage
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Role,
+		&i.Text,
+		&i.Images,
+		&i.IsSystem,
+		&i.CreatedAt,
+		&i.ToolCalls,
+		&i.ToolCallID,
+		&i.Archived,
+		&i.IsSummary,
+		&i.TgMessageIds,
+	)
+	return i, err
+}
+
+const archiveSessionMessages = `-- name: ArchiveSessionMessages :exec
+UPDATE session_messages SET archived = TRUE
+WHERE session_id = $1 AND id = ANY($2::bigint[])
+`
+
+type ArchiveSessionMessagesParams struct {
+	SessionID int64   `json:"session_id"`
+	Ids       []int64 `json:"ids"`
+}
+
+func (q *Queries) ArchiveSessionMessages(ctx context.Context, arg ArchiveSessionMessagesParams) error {
+	_, err := q.db.Exec(ctx, archiveSessionMessages, arg.SessionID, arg.Ids)
+	return err
+}
+
+const copyMessageFiles = `-- name: CopyMessageFiles :exec
+INSERT INTO message_files (message_id, file_type, url, name)
+SELECT $1::bigint, file_type, url, name
+FROM message_files WHERE message_id = $2::bigint
+`
+
+type CopyMessageFilesParams struct {
+	NewMessageID int64 `json:"new_message_id"`
+	MessageID    int64 `json:"message_id"`
+}
+
+func (q *Queries) CopyMessageFiles(ctx context.Context, arg CopyMessageFilesParams) error {
+	_, err := q.db.Exec(ctx, copyMessageFiles, arg.NewMessageID, arg.MessageID)
+	return err
+}
+
+const copySessionMessage = `-- name: CopySessionMessage :one
+INSERT INTO session_messages (session_id, role, text, images, is_system, created_at, tool_calls, tool_call_id, is_summary)
+SELECT $1::bigint, role, text, images, is_system, created_at, tool_calls, tool_call_id, is_summary
+FROM session_messages WHERE id = $2::bigint
+RETURNING id
+`
+
+type CopySessionMessageParams struct {
+	SessionID int64 `json:"session_id"`
+	ID        int64 `json:"id"`
+}
+
+func (q *Queries) CopySessionMessage(ctx context.Context, arg CopySessionMessageParams) (int64, error) {
+	row := q.db.QueryRow(ctx, copySessionMessage, arg.SessionID, arg.ID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const countSessionMessages = `-- name: CountSessionMessages :one
-SELECT COUNT(*) FROM session_messages WHERE session_id = $1
+SELECT COUNT(*) FROM session_messages WHERE session_id = $1 AND NOT archived
 `
 
 func (q *Queries) CountSessionMessages(ctx context.Context, sessionID int64) (int64, error) {
@@ -89,10 +223,49 @@ func (q *Queries) CountSessionsByUserID(ctx context.Context, userID int64) (int6
 	return count, err
 }
 
+const createForkSession = `-- name: CreateForkSession :one
+INSERT INTO chat_sessions (user_id, model, temperature, params, parent_session_id, fork_message_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, model, temperature, created_at, updated_at, params, parent_session_id, fork_message_id
+`
+
+type CreateForkSessionParams struct {
+	UserID          int64           `json:"user_id"`
+	Model           string          `json:"model"`
+	Temperature     decimal.Decimal `json:"temperature"`
+	Params          []byte          `json:"params"`
+	ParentSessionID *int64          `json:"parent_session_id"`
+	ForkMessageID   *int64          `json:"fork_message_id"`
+}
+
+func (q *Queries) CreateForkSession(ctx context.Context, arg CreateForkSessionParams) (ChatSession, error) {
+	row := q.db.QueryRow(ctx, createForkSession,
+		arg.UserID,
+		arg.Model,
+		arg.Temperature,
+		arg.Params,
+		arg.ParentSessionID,
+		arg.ForkMessageID,
+	)
+	var i ChatSession
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Model,
+		&i.Temperature,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Params,
+		&i.ParentSessionID,
+		&i.ForkMessageID,
+	)
+	return i, err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO chat_sessions (user_id, model, temperature)
 VALUES ($1, $2, $3)
-RETURNING id, user_id, model, temperature, created_at, updated_at
+RETURNING id, user_id, model, temperature, created_at, updated_at, params, parent_session_id, fork_message_id
 `
 
 type CreateSessionParams struct {
@@ -111,6 +284,9 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (C
 		&i.Temperature,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Params,
+		&i.ParentSessionID,
+		&i.ForkMessageID,
 	)
 	return i, err
 }
@@ -153,8 +329,23 @@ func (q *Queries) DeleteSession(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteSessionMessages = `-- name: DeleteSessionMessages :exec
+DELETE FROM session_messages
+WHERE session_id = $1 AND id = ANY($2::bigint[])
+`
+
+type DeleteSessionMessagesParams struct {
+	SessionID int64   `json:"session_id"`
+	Ids       []int64 `json:"ids"`
+}
+
+func (q *Queries) DeleteSessionMessages(ctx context.Context, arg DeleteSessionMessagesParams) error {
+	_, err := q.db.Exec(ctx, deleteSessionMessages, arg.SessionID, arg.Ids)
+	return err
+}
+
 const getFirstSessionMessage = `-- name: GetFirstSessionMessage :one
-SELECT id, session_id, role, text, images, is_system, created_at FROM session_messages WHERE session_id = $1 ORDER BY created_at ASC LIMIT 1
+SELECT id, session_id, role, text, images, is_system, created_at, tool_calls, tool_call_id, archived, is_summary, tg_message_ids FROM session_messages WHERE session_id = $1 ORDER BY created_at ASC LIMIT 1
 `
 
 func (q *Queries) GetFirstSessionMessage(ctx context.Context, sessionID int64) (SessionMessage, error) {
@@ -168,6 +359,11 @@ func (q *Queries) GetFirstSessionMessage(ctx context.Context, sessionID int64) (
 		&i.Images,
 		&i.IsSystem,
 		&i.CreatedAt,
+		&i.ToolCalls,
+		&i.ToolCallID,
+		&i.Archived,
+		&i.IsSummary,
+		&i.TgMessageIds,
 	)
 	return i, err
 }
@@ -203,7 +399,7 @@ func (q *Queries) GetMessageFiles(ctx context.Context, messageID int64) ([]Messa
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, user_id, model, temperature, created_at, updated_at FROM chat_sessions WHERE id = $1
+SELECT id, user_id, model, temperature, created_at, updated_at, params, parent_session_id, fork_message_id FROM chat_sessions WHERE id = $1
 `
 
 func (q *Queries) GetSessionByID(ctx context.Context, id int64) (ChatSession, error) {
@@ -216,12 +412,15 @@ func (q *Queries) GetSessionByID(ctx context.Context, id int64) (ChatSession, er
 		&i.Temperature,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Params,
+		&i.ParentSessionID,
+		&i.ForkMessageID,
 	)
 	return i, err
 }
 
 const getSessionMessages = `-- name: GetSessionMessages :many
-SELECT id, session_id, role, text, images, is_system, created_at FROM session_messages WHERE session_id = $1 ORDER BY created_at ASC
+SELECT id, session_id, role, text, images, is_system, created_at, tool_calls, tool_call_id, archived, is_summary, tg_message_ids FROM session_messages WHERE session_id = $1 AND NOT archived ORDER BY created_at ASC
 `
 
 func (q *Queries) GetSessionMessages(ctx context.Context, sessionID int64) ([]SessionMessage, error) {
@@ -241,6 +440,11 @@ func (q *Queries) GetSessionMessages(ctx context.Context, sessionID int64) ([]Se
 			&i.Images,
 			&i.IsSystem,
 			&i.CreatedAt,
+			&i.ToolCalls,
+			&i.ToolCallID,
+			&i.Archived,
+			&i.IsSummary,
+			&i.TgMessageIds,
 		); err != nil {
 			return nil, err
 		}
@@ -253,7 +457,7 @@ func (q *Queries) GetSessionMessages(ctx context.Context, sessionID int64) ([]Se
 }
 
 const getSessionsByUserID = `-- name: GetSessionsByUserID :many
-SELECT id, user_id, model, temperature, created_at, updated_at FROM chat_sessions WHERE user_id = $1 ORDER BY updated_at DESC LIMIT $2 OFFSET $3
+SELECT id, user_id, model, temperature, created_at, updated_at, params, parent_session_id, fork_message_id FROM chat_sessions WHERE user_id = $1 ORDER BY updated_at DESC LIMIT $2 OFFSET $3
 `
 
 type GetSessionsByUserIDParams struct {
@@ -278,6 +482,9 @@ func (q *Queries) GetSessionsByUserID(ctx context.Context, arg GetSessionsByUser
 			&i.Temperature,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Params,
+			&i.ParentSessionID,
+			&i.ForkMessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -289,6 +496,116 @@ func (q *Queries) GetSessionsByUserID(ctx context.Context, arg GetSessionsByUser
 	return items, nil
 }
 
+const getUserAnswerByTelegramID = `-- name: GetUserAnswerByTelegramID :one
+SELECT sm.id, sm.session_id, sm.role, sm.text, sm.images, sm.is_system, sm.created_at, sm.tool_calls, sm.tool_call_id, sm.archived, sm.is_summary, sm.tg_message_ids FROM session_messages sm
+JOIN chat_sessions cs ON cs.id = sm.session_id
+WHERE cs.user_id = $1 AND sm.role = 'assistant' AND sm.tg_message_ids @> ARRAY[$2::bigint]
+ORDER BY sm.id DESC
+LIMIT 1
+`
+
+type GetUserAnswerByTelegramIDParams struct {
+	UserID      int64 `json:"user_id"`
+	TgMessageID int64 `json:"tg_message_id"`
+}
+
+func (q *Queries) GetUserAnswerByTelegramID(ctx context.Context, arg GetUserAnswerByTelegramIDParams) (SessionMessage, error) {
+	row := q.db.QueryRow(ctx, getUserAnswerByTelegramID, arg.UserID, arg.TgMessageID)
+	var i SessionMessage
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Role,
+		&i.Text,
+		&i.Images,
+		&i.IsSystem,
+		&i.CreatedAt,
+		&i.ToolCalls,
+		&i.ToolCallID,
+		&i.Archived,
+		&i.IsSummary,
+		&i.TgMessageIds,
+	)
+	return i, err
+}
+
+const getUserSessionMessage = `-- name: GetUserSessionMessage :one
+SELECT sm.id, sm.session_id, sm.role, sm.text, sm.images, sm.is_system, sm.created_at, sm.tool_calls, sm.tool_call_id, sm.archived, sm.is_summary, sm.tg_message_ids FROM session_messages sm
+JOIN chat_sessions cs ON cs.id = sm.session_id
+WHERE sm.id = $1 AND cs.user_id = $2
+`
+
+type GetUserSessionMessageParams struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetUserSessionMessage(ctx context.Context, arg GetUserSessionMessageParams) (SessionMessage, error) {
+	row := q.db.QueryRow(ctx, getUserSessionMessage, arg.ID, arg.UserID)
+	var i SessionMessage
+	err := row.Scan(
+		&i.ID,
+		&i.SessionID,
+		&i.Role,
+		&i.Text,
+		&i.Images,
+		&i.IsSystem,
+		&i.CreatedAt,
+		&i.ToolCalls,
+		&i.ToolCallID,
+		&i.Archived,
+		&i.IsSummary,
+		&i.TgMessageIds,
+	)
+	return i, err
+}
+
+const replaceSessionModel = `-- name: ReplaceSessionModel :execrows
+UPDATE chat_sessions SET model = $1::text, updated_at = NOW()
+WHERE model = $2::text
+`
+
+type ReplaceSessionModelParams struct {
+	NewModel string `json:"new_model"`
+	OldModel string `json:"old_model"`
+}
+
+func (q *Queries) ReplaceSessionModel(ctx context.Context, arg ReplaceSessionModelParams) (int64, error) {
+	result, err := q.db.Exec(ctx, replaceSessionModel, arg.NewModel, arg.OldModel)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setSessionMessageTelegramIDs = `-- name: SetSessionMessageTelegramIDs :exec
+UPDATE session_messages SET tg_message_ids = $2 WHERE id = $1
+`
+
+type SetSessionMessageTelegramIDsParams struct {
+	ID           int64   `json:"id"`
+	TgMessageIds []int64 `json:"tg_message_ids"`
+}
+
+func (q *Queries) SetSessionMessageTelegramIDs(ctx context.Context, arg SetSessionMessageTelegramIDsParams) error {
+	_, err := q.db.Exec(ctx, setSessionMessageTelegramIDs, arg.ID, arg.TgMessageIds)
+	return err
+}
+
+const updateSessionMessageText = `-- name: UpdateSessionMessageText :exec
+UPDATE session_messages SET text = $2 WHERE id = $1
+`
+
+type UpdateSessionMessageTextParams struct {
+	ID   int64  `json:"id"`
+	Text string `json:"text"`
+}
+
+func (q *Queries) UpdateSessionMessageText(ctx context.Context, arg UpdateSessionMessageTextParams) error {
+	_, err := q.db.Exec(ctx, updateSessionMessageText, arg.ID, arg.Text)
+	return err
+}
+
 const updateSessionModel = `-- name: UpdateSessionModel :exec
 UPDATE chat_sessions SET model = $2, updated_at = NOW() WHERE id = $1
 `
@@ -303,6 +620,34 @@ func (q *Queries) UpdateSessionModel(ctx context.Context, arg UpdateSessionModel
 	return err
 }
 
+const updateSessionParams = `-- name: UpdateSessionParams :exec
+UPDATE chat_sessions SET params = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdateSessionParamsParams struct {
+	ID     int64  `json:"id"`
+	Params []byte `json:"params"`
+}
+
+func (q *Queries) UpdateSessionParams(ctx context.Context, arg UpdateSessionParamsParams) error {
+	_, err := q.db.Exec(ctx, updateSessionParams, arg.ID, arg.Params)
+	return err
+}
+
+const updateSessionTemperature = `-- name: UpdateSessionTemperature :exec
+UPDATE chat_sessions SET temperature = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdateSessionTemperatureParams struct {
+	ID          int64           `json:"id"`
+	Temperature decimal.Decimal `json:"temperature"`
+}
+
+func (q *Queries) UpdateSessionTemperature(ctx context.Context, arg UpdateSessionTemperatureParams) error {
+	_, err := q.db.Exec(ctx, updateSessionTemperature, arg.ID, arg.Temperature)
+	return err
+}
+
 const updateSessionTimestamp = `-- name: UpdateSessionTimestamp :exec
 UPDATE chat_sessions SET updated_at = NOW() WHERE id = $1
 `
@@ -48,7 +48,7 @@ func (q *Queries) CountUsersCreatedAfter(ctx context.Context, createdAt pgtype.T
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (telegram_id, first_name, username, referral_code, referred_by_id, is_admin)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at
+RETURNING id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at, voice_replies, show_reasoning, reasoning_effort, max_request_cost
 `
 
 type CreateUserParams struct {
@@ -93,12 +93,16 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.LastSkysmart,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VoiceReplies,
+		&i.ShowReasoning,
+		&i.ReasoningEffort,
+		&i.MaxRequestCost,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at FROM users WHERE id = $1
+SELECT id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at, voice_replies, show_reasoning, reasoning_effort, max_request_cost FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
@@ -127,12 +131,16 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.LastSkysmart,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VoiceReplies,
+		&i.ShowReasoning,
+		&i.ReasoningEffort,
+		&i.MaxRequestCost,
 	)
 	return i, err
 }
 
 const getUserByReferralCode = `-- name: GetUserByReferralCode :one
-SELECT id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at FROM users WHERE referral_code = $1
+SELECT id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at, voice_replies, show_reasoning, reasoning_effort, max_request_cost FROM users WHERE referral_code = $1
 `
 
 func (q *Queries) GetUserByReferralCode(ctx context.Context, referralCode string) (User, error) {
@@ -161,12 +169,16 @@ func (q *Queries) GetUserByReferralCode(ctx context.Context, referralCode string
 		&i.LastSkysmart,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VoiceReplies,
+		&i.ShowReasoning,
+		&i.ReasoningEffort,
+		&i.MaxRequestCost,
 	)
 	return i, err
 }
 
 const getUserByTelegramID = `-- name: GetUserByTelegramID :one
-SELECT id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at FROM users WHERE telegram_id = $1
+SELECT id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at, voice_replies, show_reasoning, reasoning_effort, max_request_cost FROM users WHERE telegram_id = $1
 `
 
 func (q *Queries) GetUserByTelegramID(ctx context.Context, telegramID int64) (User, error) {
@@ -195,12 +207,16 @@ func (q *Queries) GetUserByTelegramID(ctx context.Context, telegramID int64) (Us
 		&i.LastSkysmart,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VoiceReplies,
+		&i.ShowReasoning,
+		&i.ReasoningEffort,
+		&i.MaxRequestCost,
 	)
 	return i, err
 }
 
 const getUserForUpdate = `-- name: GetUserForUpdate :one
-SELECT id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at FROM users WHERE id = $1 FOR UPDATE
+SELECT id, telegram_id, is_admin, first_name, username, balance, referral_code, referral_balance, referred_by_id, premium_until, active_session_id, last_interaction, selected_model, favorite_models, temperature, show_cost, send_user_info, context_enabled, session_timeout_ms, last_skysmart, created_at, updated_at, voice_replies, show_reasoning, reasoning_effort, max_request_cost FROM users WHERE id = $1 FOR UPDATE
 `
 
 func (q *Queries) GetUserForUpdate(ctx context.Context, id int64) (User, error) {
@@ -229,10 +245,52 @@ func (q *Queries) GetUserForUpdate(ctx context.Context, id int64) (User, error)
 		&i.LastSkysmart,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.VoiceReplies,
+		&i.ShowReasoning,
+		&i.ReasoningEffort,
+		&i.MaxRequestCost,
 	)
 	return i, err
 }
 
+const replaceUserModel = `-- name: ReplaceUserModel :many
+UPDATE users SET
+    selected_model = CASE WHEN selected_model = $1::text THEN $2::text ELSE selected_model END,
+    favorite_models = CASE
+        WHEN NOT ($1::text = ANY(favorite_models)) THEN favorite_models
+        WHEN $2::text = ANY(favorite_models) THEN array_remove(favorite_models, $1::text)
+        ELSE array_replace(favorite_models, $1::text, $2::text)
+    END,
+    updated_at = NOW()
+WHERE selected_model = $1::text OR $1::text = ANY(favorite_models)
+RETURNING telegram_id
+`
+
+type ReplaceUserModelParams struct {
+	OldModel string `json:"old_model"`
+	NewModel string `json:"new_model"`
+}
+
+func (q *Queries) ReplaceUserModel(ctx context.Context, arg ReplaceUserModelParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, replaceUserModel, arg.OldModel, arg.NewModel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var telegram_id int64
+		if err := rows.Scan(&telegram_id); err != nil {
+			return nil, err
+		}
+		items = append(items, telegram_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const setUserActiveSession = `-- name: SetUserActiveSession :exec
 UPDATE users SET active_session_id = $2, updated_at = NOW() WHERE id = $1
 `
@@ -275,6 +333,20 @@ func (q *Queries) SetUserIsAdmin(ctx context.Context, arg SetUserIsAdminParams)
 	return err
 }
 
+const setUserMaxRequestCost = `-- name: SetUserMaxRequestCost :exec
+UPDATE users SET max_request_cost = $2, updated_at = NOW() WHERE id = $1
+`
+
+type SetUserMaxRequestCostParams struct {
+	ID             int64           `json:"id"`
+	MaxRequestCost decimal.Decimal `json:"max_request_cost"`
+}
+
+func (q *Queries) SetUserMaxRequestCost(ctx context.Context, arg SetUserMaxRequestCostParams) error {
+	_, err := q.db.Exec(ctx, setUserMaxRequestCost, arg.ID, arg.MaxRequestCost)
+	return err
+}
+
 const setUserPremiumUntil = `-- name: SetUserPremiumUntil :exec
 UPDATE users SET premium_until = $2, updated_at = NOW() WHERE id = $1
 `
@@ -289,6 +361,20 @@ func (q *Queries) SetUserPremiumUntil(ctx context.Context, arg SetUserPremiumUnt
 	return err
 }
 
+const setUserReasoningEffort = `-- name: SetUserReasoningEffort :exec
+UPDATE users SET reasoning_effort = $2, updated_at = NOW() WHERE id = $1
+`
+
+type SetUserReasoningEffortParams struct {
+	ID              int64  `json:"id"`
+	ReasoningEffort string `json:"reasoning_effort"`
+}
+
+func (q *Queries) SetUserReasoningEffort(ctx context.Context, arg SetUserReasoningEffortParams) error {
+	_, err := q.db.Exec(ctx, setUserReasoningEffort, arg.ID, arg.ReasoningEffort)
+	return err
+}
+
 const setUserSelectedModel = `-- name: SetUserSelectedModel :exec
 UPDATE users SET selected_model = $2, updated_at = NOW() WHERE id = $1
 `
@@ -358,6 +444,24 @@ func (q *Queries) ToggleUserShowCost(ctx context.Context, id int64) error {
 	return err
 }
 
+const toggleUserShowReasoning = `-- name: ToggleUserShowReasoning :exec
+UPDATE users SET show_reasoning = NOT show_reasoning, updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) ToggleUserShowReasoning(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, toggleUserShowReasoning, id)
+	return err
+}
+
+const toggleUserVoiceReplies = `-- name: ToggleUserVoiceReplies :exec
+UPDATE users SET voice_replies = NOT voice_replies, updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) ToggleUserVoiceReplies(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, toggleUserVoiceReplies, id)
+	return err
+}
+
 const updateUserBalance = `-- name: UpdateUserBalance :one
 UPDATE users SET balance = balance + $2, updated_at = NOW() WHERE id = $1
 RETURNING balance
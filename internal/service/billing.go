@@ -243,10 +243,53 @@ func (s *BillingService) TransferUserToGroup(ctx context.Context, userID, groupI
 	return tx.Commit(ctx)
 }
 
-// CalculateCost calculates AI request cost with markup.
-func CalculateCost(promptTokens, completionTokens int, promptPrice, completionPrice float64, markupPercent float64) decimal.Decimal {
-	promptCost := decimal.NewFromFloat(float64(promptTokens) * promptPrice / 1_000_000)
-	completionCost := decimal.NewFromFloat(float64(completionTokens) * completionPrice / 1_000_000)
+// AnswerCost prices an AI answer with markup: the cost the provider reported,
+// which already includes images, or else its tokens, generated and attached
+// images and the per-request fee. Every charge for an answer goes through it.
+func AnswerCost(usage ChatUsage, model *domain.AIModel, generatedImages, inputImages int, markupPercent float64) decimal.Decimal {
+	if usage.TotalCost > 0 {
+		markup := decimal.NewFromFloat(1 + markupPercent/100)
+		return decimal.NewFromFloat(usage.TotalCost).Mul(markup)
+	}
+	return CalculateCost(usage, model, markupPercent).
+		Add(CalculateImageCost(generatedImages, model.ImagePrice, markupPercent)).
+		Add(CalculateImageCost(inputImages, model.InputImagePrice, markupPercent)).
+		Add(CalculateRequestCost(model.RequestPrice, markupPercent))
+}
+
+// CalculateImageCost calculates the cost of generated images with markup.
+func CalculateImageCost(images int, imagePrice float64, markupPercent float64) decimal.Decimal {
+	baseCost := decimal.NewFromFloat(float64(images) * imagePrice)
+	markup := decimal.NewFromFloat(1 + markupPercent/100)
+	return baseCost.Mul(markup)
+}
+
+// CalculateRequestCost calculates the flat per-request fee with markup.
+func CalculateRequestCost(requestPrice float64, markupPercent float64) decimal.Decimal {
+	markup := decimal.NewFromFloat(1 + markupPercent/100)
+	return decimal.NewFromFloat(requestPrice).Mul(markup)
+}
+
+// CalculateCost calculates the token cost of an AI request with markup.
+// Prompt tokens read from the prompt cache are charged at the model's cached
+// price and the ones written to it at the cache-write price.
+func CalculateCost(usage ChatUsage, model *domain.AIModel, markupPercent float64) decimal.Decimal {
+	cached := usage.PromptTokensDetails.CachedTokens
+	written := usage.PromptTokensDetails.CacheWriteTokens
+	uncached := max(usage.PromptTokens-cached-written, 0)
+
+	readPrice, writePrice := model.CacheReadPrice, model.CacheWritePrice
+	if readPrice == 0 {
+		readPrice = model.PromptPrice
+	}
+	if writePrice == 0 {
+		writePrice = model.PromptPrice
+	}
+
+	promptCost := decimal.NewFromFloat(float64(uncached) * model.PromptPrice / 1_000_000).
+		Add(decimal.NewFromFloat(float64(cached) * readPrice / 1_000_000)).
+		Add(decimal.NewFromFloat(float64(written) * writePrice / 1_000_000))
+	completionCost := decimal.NewFromFloat(float64(usage.CompletionTokens) * model.CompletionPrice / 1_000_000)
 	baseCost := promptCost.Add(completionCost)
 	markup := decimal.NewFromFloat(1 + markupPercent/100)
 	return baseCost.Mul(markup)
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func TestCalculateCost(t *testing.T) {
+	cached := &domain.AIModel{PromptPrice: 3, CompletionPrice: 15, CacheReadPrice: 0.3, CacheWritePrice: 3.75}
+	noCachePrices := &domain.AIModel{PromptPrice: 3, CompletionPrice: 15}
+
+	usage := func(prompt, completion, read, write int) ChatUsage {
+		u := ChatUsage{PromptTokens: prompt, CompletionTokens: completion}
+		u.PromptTokensDetails.CachedTokens = read
+		u.PromptTokensDetails.CacheWriteTokens = write
+		return u
+	}
+
+	tests := []struct {
+		name   string
+		usage  ChatUsage
+		model  *domain.AIModel
+		markup float64
+		want   string
+	}{
+		{"prompt and completion", usage(1_000_000, 100_000, 0, 0), cached, 0, "4.5"},
+		{"markup", usage(1_000_000, 100_000, 0, 0), cached, 50, "6.75"},
+		{"cache read and write", usage(1_000_000, 0, 400_000, 100_000), cached, 0, "1.995"},
+		{"cache prices unknown", usage(1_000_000, 0, 400_000, 100_000), noCachePrices, 0, "3"},
+		{"cached tokens beyond the prompt", usage(100, 0, 200, 0), cached, 0, "0.00006"},
+		{"nothing", ChatUsage{}, cached, 20, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateCost(tt.usage, tt.model, tt.markup).Round(10)
+			if want := decimal.RequireFromString(tt.want); !got.Equal(want) {
+				t.Errorf("CalculateCost() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestAnswerCost(t *testing.T) {
+	model := &domain.AIModel{PromptPrice: 1, CompletionPrice: 2, ImagePrice: 0.04, InputImagePrice: 0.01, RequestPrice: 0.005}
+	usage := ChatUsage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000}
+
+	got := AnswerCost(usage, model, 2, 3, 0).Round(10)
+	if want := decimal.RequireFromString("3.115"); !got.Equal(want) {
+		t.Errorf("AnswerCost() = %s, want %s", got, want)
+	}
+
+	// A reported cost already includes images and fees
+	usage.TotalCost = 0.5
+	got = AnswerCost(usage, model, 2, 3, 10).Round(10)
+	if want := decimal.RequireFromString("0.55"); !got.Equal(want) {
+		t.Errorf("AnswerCost() with total cost = %s, want %s", got, want)
+	}
+}
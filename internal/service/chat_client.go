@@ -0,0 +1,358 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type ChatMessage struct {
+	Role       string      `json:"role"`
+	Content    interface{} `json:"content"`
+	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+}
+
+type ChatRequest struct {
+	Model            string           `json:"model"`
+	Messages         []ChatMessage    `json:"messages"`
+	Temperature      *float64         `json:"temperature,omitempty"`
+	TopP             *float64         `json:"top_p,omitempty"`
+	MaxTokens        *int             `json:"max_tokens,omitempty"`
+	FrequencyPenalty *float64         `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64         `json:"presence_penalty,omitempty"`
+	Seed             *int64           `json:"seed,omitempty"`
+	Stop             []string         `json:"stop,omitempty"`
+	ResponseFormat   *ResponseFormat  `json:"response_format,omitempty"`
+	Tools            []ToolDefinition `json:"tools,omitempty"`
+	ToolChoice       string           `json:"tool_choice,omitempty"`
+	Modalities       []string         `json:"modalities,omitempty"`
+	Reasoning        *ReasoningConfig `json:"reasoning,omitempty"`
+	ReasoningEffort  string           `json:"reasoning_effort,omitempty"` // OpenAI form of Reasoning.Effort
+	Stream           bool             `json:"stream,omitempty"`
+	StreamOptions    *StreamOptions   `json:"stream_options,omitempty"`
+}
+
+// ResponseFormat asks for a JSON answer: any object for type "json_object",
+// or one matching JSONSchema for type "json_schema".
+type ResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+type JSONSchemaFormat struct {
+	Name   string          `json:"name"`
+	Strict bool            `json:"strict"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// ReasoningConfig controls the thinking of reasoning models. Effort is low,
+// medium or high; Exclude leaves the reasoning text out of the response,
+// though it is still generated and billed.
+type ReasoningConfig struct {
+	Effort  string `json:"effort,omitempty"`
+	Exclude bool   `json:"exclude,omitempty"`
+}
+
+// ChatImage is an image generated by the model, as a data URL or a link.
+type ChatImage struct {
+	Type     string `json:"type"`
+	ImageURL struct {
+		URL string `json:"url"`
+	} `json:"image_url"`
+}
+
+// ToolDefinition advertises a function the model may call.
+type ToolDefinition struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolCall is a function call requested by the model. Arguments is a JSON object
+// encoded as a string.
+type ToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// StreamOptions asks the API to append a final chunk with token usage to a stream.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type ChatResponse struct {
+	ID      string       `json:"id"`
+	Choices []ChatChoice `json:"choices"`
+	Usage   ChatUsage    `json:"usage"`
+}
+
+type ChatChoice struct {
+	Message struct {
+		Content          string      `json:"content"`
+		Reasoning        string      `json:"reasoning,omitempty"`
+		ReasoningContent string      `json:"reasoning_content,omitempty"` // DeepSeek and vLLM
+		ToolCalls        []ToolCall  `json:"tool_calls,omitempty"`
+		Images           []ChatImage `json:"images,omitempty"`
+	} `json:"message"`
+	FinishReason string `json:"finish_reason"`
+}
+
+// ImageURLs returns the images generated in the first choice.
+func (r *ChatResponse) ImageURLs() []string {
+	if len(r.Choices) == 0 {
+		return nil
+	}
+	var urls []string
+	for _, img := range r.Choices[0].Message.Images {
+		if img.ImageURL.URL != "" {
+			urls = append(urls, img.ImageURL.URL)
+		}
+	}
+	return urls
+}
+
+// ReasoningText returns the model's reasoning in the first choice, if any.
+func (r *ChatResponse) ReasoningText() string {
+	if len(r.Choices) == 0 {
+		return ""
+	}
+	if msg := r.Choices[0].Message; msg.Reasoning != "" {
+		return msg.Reasoning
+	}
+	return r.Choices[0].Message.ReasoningContent
+}
+
+type ChatUsage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalCost        float64 `json:"total_cost"`
+	// Cached and cache-write tokens are part of PromptTokens
+	PromptTokensDetails struct {
+		CachedTokens     int `json:"cached_tokens"`
+		CacheWriteTokens int `json:"cache_write_tokens"`
+	} `json:"prompt_tokens_details"`
+	// Reasoning tokens are part of CompletionTokens
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+	// GenerationIDs are the provider's IDs of the generations this usage
+	// covers, one per round of a tool loop
+	GenerationIDs []string `json:"-"`
+}
+
+// chatStreamChunk is a single SSE event of a streamed chat completion.
+type chatStreamChunk struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Delta struct {
+			Content          string      `json:"content"`
+			Reasoning        string      `json:"reasoning"`
+			ReasoningContent string      `json:"reasoning_content"`
+			Images           []ChatImage `json:"images"`
+			ToolCalls        []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *ChatUsage `json:"usage"`
+	Error *struct {
+		Code    interface{} `json:"code"`
+		Message string      `json:"message"`
+	} `json:"error"`
+}
+
+// chatClient talks to an OpenAI-compatible /chat/completions endpoint.
+type chatClient struct {
+	name       string
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+func (c *chatClient) chat(ctx context.Context, chatReq ChatRequest) (*ChatResponse, error) {
+	resp, err := c.postChat(ctx, chatReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	var chatResp ChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	if chatResp.ID != "" {
+		chatResp.Usage.GenerationIDs = []string{chatResp.ID}
+	}
+
+	// Some providers report failures in a 200 response without choices
+	if len(chatResp.Choices) == 0 {
+		var errBody upstreamErrorBody
+		if json.Unmarshal(body, &errBody) == nil && errBody.Error.Message != "" {
+			return nil, newStreamError(c.name, errBody.Error.Code, errBody.Error.Message)
+		}
+	}
+
+	return &chatResp, nil
+}
+
+// chatStream sends a streaming chat request and calls onDelta for every piece of
+// generated text as it arrives. The returned response carries the full text,
+// the reasoning, generated images, any tool calls assembled from their fragments and the usage
+// block sent with the final chunk. A stream that ends without [DONE] or a
+// finish reason is reported as cut off by the length limit.
+func (c *chatClient) chatStream(ctx context.Context, chatReq ChatRequest, onDelta func(delta string)) (*ChatResponse, error) {
+	chatReq.Stream = true
+	chatReq.StreamOptions = &StreamOptions{IncludeUsage: true}
+
+	resp, err := c.postChat(ctx, chatReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content, reasoning strings.Builder
+	var usage ChatUsage
+	var toolCalls []ToolCall
+	var images []ChatImage
+	var finishReason, id string
+	var done bool
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Blank lines separate events, lines starting with ':' are keep-alive comments
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("parse stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return nil, newStreamError(c.name, chunk.Error.Code, chunk.Error.Message)
+		}
+		if chunk.ID != "" {
+			id = chunk.ID
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+			images = append(images, choice.Delta.Images...)
+			reasoning.WriteString(choice.Delta.Reasoning)
+			reasoning.WriteString(choice.Delta.ReasoningContent)
+			// Tool calls arrive in fragments keyed by index: the first one carries
+			// the ID and name, the rest append to the arguments
+			for _, tc := range choice.Delta.ToolCalls {
+				for len(toolCalls) <= tc.Index {
+					toolCalls = append(toolCalls, ToolCall{Type: "function"})
+				}
+				call := &toolCalls[tc.Index]
+				if tc.ID != "" {
+					call.ID = tc.ID
+				}
+				call.Function.Name += tc.Function.Name
+				call.Function.Arguments += tc.Function.Arguments
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read stream: %w", err)
+	}
+	// The connection closed mid-answer: the text shown so far is kept as a cut
+	// off answer that can be continued, anything else is retried
+	if !done && finishReason == "" {
+		if content.Len() == 0 || len(toolCalls) > 0 {
+			return nil, fmt.Errorf("read stream: %w", io.ErrUnexpectedEOF)
+		}
+		finishReason = "length"
+	}
+
+	if id != "" {
+		usage.GenerationIDs = []string{id}
+	}
+	chatResp := &ChatResponse{
+		ID:      id,
+		Choices: make([]ChatChoice, 1),
+		Usage:   usage,
+	}
+	chatResp.Choices[0].Message.Content = content.String()
+	chatResp.Choices[0].Message.Reasoning = reasoning.String()
+	chatResp.Choices[0].Message.ToolCalls = toolCalls
+	chatResp.Choices[0].Message.Images = images
+	chatResp.Choices[0].FinishReason = finishReason
+	return chatResp, nil
+}
+
+// postChat sends a chat completion request and returns the response with an open
+// body, or an *UpstreamError for non-successful statuses.
+func (c *chatClient) postChat(ctx context.Context, chatReq ChatRequest) (*http.Response, error) {
+	payload, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("chat request: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newUpstreamError(c.name, resp)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChatStreamEnd(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantReason string
+		wantErr    bool
+	}{
+		{
+			name:       "done",
+			body:       "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n",
+			wantReason: "stop",
+		},
+		{
+			name:       "finish reason without done",
+			body:       "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"},\"finish_reason\":\"stop\"}]}\n\n",
+			wantReason: "stop",
+		},
+		{
+			name:       "cut off after text",
+			body:       "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n",
+			wantReason: "length",
+		},
+		{
+			name:    "cut off before text",
+			body:    ": keep-alive\n\n",
+			wantErr: true,
+		},
+		{
+			name:    "cut off in a tool call",
+			body:    "data: {\"choices\":[{\"delta\":{\"content\":\"hi\",\"tool_calls\":[{\"index\":0,\"id\":\"c\",\"function\":{\"name\":\"calc\"}}]}}]}\n\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			c := &chatClient{name: "test", baseURL: srv.URL, httpClient: srv.Client()}
+			resp, err := c.chatStream(context.Background(), ChatRequest{Model: "m"}, nil)
+			if tt.wantErr {
+				if !errors.Is(err, io.ErrUnexpectedEOF) {
+					t.Fatalf("chatStream() error = %v, want io.ErrUnexpectedEOF", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("chatStream() error = %v", err)
+			}
+			if got := resp.Choices[0].FinishReason; got != tt.wantReason {
+				t.Errorf("FinishReason = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitStatus is a snapshot of one model's circuit for the admin view.
+type CircuitStatus struct {
+	Model     string
+	State     CircuitState
+	Failures  int
+	OpenedAt  time.Time
+	LastError string
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	lastError string
+	probing   bool
+}
+
+// CircuitBreaker stops sending requests to a model after repeated upstream
+// failures. After the cooldown a single probe request is let through; its
+// outcome closes the circuit or opens it again.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	circuits  map[string]*circuit
+	threshold int
+	cooldown  time.Duration
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		circuits:  make(map[string]*circuit),
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow returns domain.ErrCircuitOpen if requests to the model are blocked.
+func (cb *CircuitBreaker) Allow(model string) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[model]
+	if !ok {
+		return nil
+	}
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < cb.cooldown {
+			return domain.ErrCircuitOpen
+		}
+		c.state = CircuitHalfOpen
+		c.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if c.probing {
+			return domain.ErrCircuitOpen
+		}
+		c.probing = true
+	}
+	return nil
+}
+
+func (cb *CircuitBreaker) Success(model string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	delete(cb.circuits, model)
+}
+
+func (cb *CircuitBreaker) Failure(model string, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[model]
+	if !ok {
+		c = &circuit{state: CircuitClosed}
+		cb.circuits[model] = c
+	}
+	c.failures++
+	c.lastError = err.Error()
+	if len([]rune(c.lastError)) > 200 {
+		c.lastError = string([]rune(c.lastError)[:200]) + "…"
+	}
+	c.probing = false
+	if c.state == CircuitHalfOpen || c.failures >= cb.threshold {
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+	}
+}
+
+// Release ends a request that says nothing about the model's health, such as
+// one cancelled by the caller. A probe it was making may be retried by the
+// next request.
+func (cb *CircuitBreaker) Release(model string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if c, ok := cb.circuits[model]; ok {
+		c.probing = false
+	}
+}
+
+// Reset closes a model's circuit.
+func (cb *CircuitBreaker) Reset(model string) {
+	cb.Success(model)
+}
+
+// Snapshot returns all models with recorded failures, open circuits first.
+func (cb *CircuitBreaker) Snapshot() []CircuitStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	statuses := make([]CircuitStatus, 0, len(cb.circuits))
+	for model, c := range cb.circuits {
+		state := c.state
+		if state == CircuitOpen && time.Since(c.openedAt) >= cb.cooldown {
+			state = CircuitHalfOpen
+		}
+		statuses = append(statuses, CircuitStatus{
+			Model:     model,
+			State:     state,
+			Failures:  c.failures,
+			OpenedAt:  c.openedAt,
+			LastError: c.lastError,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].State != statuses[j].State {
+			return statuses[i].State == CircuitOpen
+		}
+		return statuses[i].Failures > statuses[j].Failures
+	})
+	return statuses
+}
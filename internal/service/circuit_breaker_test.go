@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+var errUpstream = errors.New("upstream failed")
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(3, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		cb.Failure("m", errUpstream)
+		if err := cb.Allow("m"); err != nil {
+			t.Fatalf("after %d failures: Allow() = %v, want nil", i+1, err)
+		}
+	}
+	cb.Failure("m", errUpstream)
+	if err := cb.Allow("m"); !errors.Is(err, domain.ErrCircuitOpen) {
+		t.Fatalf("after threshold: Allow() = %v, want ErrCircuitOpen", err)
+	}
+	if err := cb.Allow("other"); err != nil {
+		t.Fatalf("other model: Allow() = %v, want nil", err)
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Hour)
+
+	cb.Failure("m", errUpstream)
+	cb.Success("m")
+	cb.Failure("m", errUpstream)
+	if err := cb.Allow("m"); err != nil {
+		t.Fatalf("Allow() = %v, want nil: failures before a success must not count", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name      string
+		finish    func(cb *CircuitBreaker)
+		wantAllow error
+	}{
+		{"success closes", func(cb *CircuitBreaker) { cb.Success("m") }, nil},
+		{"failure reopens", func(cb *CircuitBreaker) { cb.Failure("m", errUpstream) }, domain.ErrCircuitOpen},
+		{"release lets the next request probe", func(cb *CircuitBreaker) { cb.Release("m") }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(1, 0)
+			cb.Failure("m", errUpstream)
+
+			// The cooldown is over: one probe goes through, others wait for it
+			if err := cb.Allow("m"); err != nil {
+				t.Fatalf("probe: Allow() = %v, want nil", err)
+			}
+			if err := cb.Allow("m"); !errors.Is(err, domain.ErrCircuitOpen) {
+				t.Fatalf("during probe: Allow() = %v, want ErrCircuitOpen", err)
+			}
+
+			tt.finish(cb)
+			cb.cooldown = time.Hour
+			if err := cb.Allow("m"); !errors.Is(err, tt.wantAllow) {
+				t.Fatalf("after probe: Allow() = %v, want %v", err, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerSnapshot(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Hour)
+	cb.Failure("closed", errUpstream)
+	cb.Failure("open", errUpstream)
+	cb.Failure("open", errUpstream)
+
+	got := cb.Snapshot()
+	if len(got) != 2 {
+		t.Fatalf("Snapshot() has %d entries, want 2", len(got))
+	}
+	if got[0].Model != "open" || got[0].State != CircuitOpen || got[0].Failures != 2 {
+		t.Errorf("first entry = %+v, want the open circuit", got[0])
+	}
+	if got[1].Model != "closed" || got[1].State != CircuitClosed {
+		t.Errorf("second entry = %+v, want the closed circuit", got[1])
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CompareResult is one model's answer in a comparison.
+type CompareResult struct {
+	Response *ChatResponse
+	Latency  time.Duration
+	Err      error
+}
+
+// Compare sends the requests in parallel, typically the same prompt to
+// different models, and returns the results in the same order.
+func (r *LLMRouter) Compare(ctx context.Context, reqs []ChatRequest) []CompareResult {
+	results := make([]CompareResult, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			resp, err := r.Chat(ctx, req)
+			results[i] = CompareResult{Response: resp, Latency: time.Since(started), Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
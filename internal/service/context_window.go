@@ -0,0 +1,176 @@
+package service
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+// Token costs of message parts that are not text. Providers count images and
+// audio by size; these are typical values, erring on the high side.
+const (
+	messageOverheadTokens = 4
+	imagePartTokens       = 1000
+	audioPartTokens       = 2000
+	filePartTokens        = 4000
+)
+
+// EstimateTokens approximates the number of tokens a BPE tokenizer produces
+// for text. The text is split the way GPT-style pre-tokenizers do (words,
+// numbers, punctuation, whitespace) and each piece is costed by its script:
+// English words average about four characters per token, Cyrillic and other
+// alphabets about two, CJK characters and punctuation one each.
+func EstimateTokens(text string) int {
+	tokens := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case unicode.IsLetter(r) && !isCJK(r):
+			ascii, other := 0, 0
+			j := i
+			for j < len(text) {
+				r, size := utf8.DecodeRuneInString(text[j:])
+				if !unicode.IsLetter(r) || isCJK(r) {
+					break
+				}
+				if r < utf8.RuneSelf {
+					ascii++
+				} else {
+					other++
+				}
+				j += size
+			}
+			tokens += ceilDiv(ascii, 4) + ceilDiv(other, 2)
+			i = j
+		case unicode.IsDigit(r):
+			// Numbers are split into groups of up to three digits
+			n := 0
+			for i < len(text) && text[i] >= '0' && text[i] <= '9' {
+				n++
+				i++
+			}
+			if n == 0 {
+				n, i = 1, i+size
+			}
+			tokens += ceilDiv(n, 3)
+		case unicode.IsSpace(r):
+			// Runs of whitespace merge into a single token
+			for i < len(text) {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				i += size
+			}
+			tokens++
+		default:
+			tokens++
+			i += size
+		}
+	}
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// EstimateContentTokens estimates a chat message content: a string or a list
+// of content parts.
+func EstimateContentTokens(content interface{}) int {
+	switch c := content.(type) {
+	case string:
+		return EstimateTokens(c)
+	case []interface{}:
+		tokens := 0
+		for _, part := range c {
+			p, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch p["type"] {
+			case "text":
+				text, _ := p["text"].(string)
+				tokens += EstimateTokens(text)
+			case "image_url":
+				tokens += imagePartTokens
+			case "input_audio":
+				tokens += audioPartTokens
+			case "file":
+				tokens += filePartTokens
+			}
+		}
+		return tokens
+	}
+	return 0
+}
+
+func estimateMessageTokens(m domain.SessionMessage) int {
+	tokens := messageOverheadTokens + EstimateTokens(m.Text)
+	for _, c := range m.ToolCalls {
+		tokens += EstimateTokens(c.Name) + EstimateTokens(c.Arguments)
+	}
+	return tokens
+}
+
+// ContextBudget returns how many prompt tokens a request to the model may use:
+// its context length minus the room reserved for the answer. Zero means the
+// context length is unknown.
+func ContextBudget(model *domain.AIModel) int {
+	if model.ContextLength <= 0 {
+		return 0
+	}
+	reserve := min(config.ContextCompletionReserve, model.ContextLength/4)
+	return model.ContextLength - reserve
+}
+
+// FitHistory drops the oldest turns of the history until it fits into budget
+// tokens. A turn is a user message with everything that follows it up to the
+// next one, so tool calls are never separated from their results. System
+// prompts are always kept, so a budget of zero or less leaves only them. It
+// returns the kept messages and how many were dropped. Callers skip trimming
+// when ContextBudget reports an unknown context length.
+func FitHistory(history []domain.SessionMessage, budget int) ([]domain.SessionMessage, int) {
+	total := 0
+	for _, m := range history {
+		total += estimateMessageTokens(m)
+	}
+	if total <= budget {
+		return history, 0
+	}
+
+	// Mark whole turns for removal, oldest first
+	drop := make([]bool, len(history))
+	dropped := 0
+	for i := 0; i < len(history) && total > budget; {
+		if history[i].IsSystem {
+			i++
+			continue
+		}
+		// Remove the turn starting at i
+		for {
+			total -= estimateMessageTokens(history[i])
+			drop[i] = true
+			dropped++
+			i++
+			if i >= len(history) || history[i].IsSystem || history[i].Role == "user" {
+				break
+			}
+		}
+	}
+
+	kept := make([]domain.SessionMessage, 0, len(history)-dropped)
+	for i, m := range history {
+		if !drop[i] {
+			kept = append(kept, m)
+		}
+	}
+	return kept, dropped
+}
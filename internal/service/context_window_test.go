@@ -0,0 +1,110 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 2},
+		{"hello world", 5},
+		{"привет", 3},
+		{"12345", 2},
+		{"你好", 2},
+		{"a, b", 4},
+		{"line\n\n\nnext", 3},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateContentTokens(t *testing.T) {
+	parts := []interface{}{
+		map[string]interface{}{"type": "text", "text": "hello"},
+		map[string]interface{}{"type": "image_url"},
+		map[string]interface{}{"type": "input_audio"},
+		map[string]interface{}{"type": "file"},
+	}
+	want := 2 + imagePartTokens + audioPartTokens + filePartTokens
+	if got := EstimateContentTokens(parts); got != want {
+		t.Errorf("EstimateContentTokens(parts) = %d, want %d", got, want)
+	}
+	if got := EstimateContentTokens("hello"); got != 2 {
+		t.Errorf("EstimateContentTokens(string) = %d, want 2", got)
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	tests := []struct {
+		contextLength int
+		want          int
+	}{
+		{0, 0},
+		{8192, 6144},
+		{200_000, 195_904},
+	}
+	for _, tt := range tests {
+		if got := ContextBudget(&domain.AIModel{ContextLength: tt.contextLength}); got != tt.want {
+			t.Errorf("ContextBudget(%d) = %d, want %d", tt.contextLength, got, tt.want)
+		}
+	}
+}
+
+func TestFitHistory(t *testing.T) {
+	// Every message costs messageOverheadTokens plus one token of text
+	msg := func(role string) domain.SessionMessage {
+		return domain.SessionMessage{Role: role, Text: "x"}
+	}
+	system := domain.SessionMessage{Role: "system", Text: "x", IsSystem: true}
+	toolCall := msg("assistant")
+	toolCall.ToolCalls = []domain.ToolCall{{Name: "f", Arguments: "x"}}
+	per := messageOverheadTokens + 1
+
+	history := []domain.SessionMessage{
+		system,
+		msg("user"), toolCall, msg("tool"), msg("assistant"),
+		msg("user"), msg("assistant"),
+	}
+	total := 0
+	for _, m := range history {
+		total += estimateMessageTokens(m)
+	}
+
+	tests := []struct {
+		name        string
+		budget      int
+		wantRoles   []string
+		wantDropped int
+	}{
+		{"fits", total, []string{"system", "user", "assistant", "tool", "assistant", "user", "assistant"}, 0},
+		{"drops the oldest turn with its tool calls", total - 1, []string{"system", "user", "assistant"}, 4},
+		{"keeps the system prompt", per, []string{"system"}, 6},
+		{"no room left", 0, []string{"system"}, 6},
+		{"prompt exceeds the context", -100, []string{"system"}, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := FitHistory(history, tt.budget)
+			if dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", dropped, tt.wantDropped)
+			}
+			if len(kept) != len(tt.wantRoles) {
+				t.Fatalf("kept %d messages, want %d", len(kept), len(tt.wantRoles))
+			}
+			for i, m := range kept {
+				if m.Role != tt.wantRoles[i] {
+					t.Errorf("kept[%d].Role = %s, want %s", i, m.Role, tt.wantRoles[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+// CostEstimate is the expected cost of a request before it is sent. The prompt
+// size is estimated, so both bounds are approximate.
+type CostEstimate struct {
+	PromptTokens        int
+	MaxCompletionTokens int
+	Min                 decimal.Decimal // prompt and fixed fees, without an answer
+	Max                 decimal.Decimal // with the longest answer the model may give
+
+	completionPrice decimal.Decimal // per token, with markup
+}
+
+// EstimateCost estimates a request of messages with inputImages attached
+// images, including markup.
+func EstimateCost(model *domain.AIModel, messages []ChatMessage, inputImages int, markupPercent float64) CostEstimate {
+	promptTokens := EstimateChatTokens(messages)
+	maxCompletion := MaxCompletionTokens(model, promptTokens)
+
+	fixed := CalculateImageCost(inputImages, model.InputImagePrice, markupPercent).
+		Add(CalculateRequestCost(model.RequestPrice, markupPercent))
+	return CostEstimate{
+		PromptTokens:        promptTokens,
+		MaxCompletionTokens: maxCompletion,
+		Min:                 CalculateCost(ChatUsage{PromptTokens: promptTokens}, model, markupPercent).Add(fixed),
+		Max:                 CalculateCost(ChatUsage{PromptTokens: promptTokens, CompletionTokens: maxCompletion}, model, markupPercent).Add(fixed),
+		completionPrice:     CalculateCost(ChatUsage{CompletionTokens: 1}, model, markupPercent),
+	}
+}
+
+// LimitCompletion returns the estimate for answers of at most tokens, such as
+// a max_tokens set for the session. It only applies to models that honour
+// max_tokens.
+func (e CostEstimate) LimitCompletion(tokens int) CostEstimate {
+	if tokens < 0 || tokens >= e.MaxCompletionTokens {
+		return e
+	}
+	e.MaxCompletionTokens = tokens
+	e.Max = e.Min.Add(e.completionPrice.Mul(decimal.NewFromInt(int64(tokens))))
+	return e
+}
+
+// TokensWithin returns how many completion tokens fit into limit after the
+// prompt is paid for; zero if the prompt alone costs more.
+func (e CostEstimate) TokensWithin(limit decimal.Decimal) int {
+	left := limit.Sub(e.Min)
+	if !left.IsPositive() {
+		return 0
+	}
+	if !e.completionPrice.IsPositive() {
+		return e.MaxCompletionTokens
+	}
+	tokens := int(left.Div(e.completionPrice).IntPart())
+	return min(tokens, e.MaxCompletionTokens)
+}
+
+// EstimateChatTokens estimates the prompt tokens of a chat request.
+func EstimateChatTokens(messages []ChatMessage) int {
+	tokens := 0
+	for _, m := range messages {
+		tokens += messageOverheadTokens + EstimateContentTokens(m.Content)
+		for _, c := range m.ToolCalls {
+			tokens += EstimateTokens(c.Function.Name) + EstimateTokens(c.Function.Arguments)
+		}
+	}
+	return tokens
+}
+
+// MaxCompletionTokens returns the longest answer the model can give after a
+// prompt of promptTokens: its output limit, or the rest of its context window.
+// If neither is known, the context reserve is used.
+func MaxCompletionTokens(model *domain.AIModel, promptTokens int) int {
+	tokens := model.MaxOutputTokens
+	if model.ContextLength > 0 {
+		room := max(model.ContextLength-promptTokens, 0)
+		if tokens == 0 || room < tokens {
+			tokens = room
+		}
+	}
+	if tokens == 0 && model.ContextLength == 0 {
+		tokens = config.ContextCompletionReserve
+	}
+	return tokens
+}
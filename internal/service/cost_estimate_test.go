@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/shopspring/decimal"
+)
+
+func TestMaxCompletionTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  domain.AIModel
+		prompt int
+		want   int
+	}{
+		{"output limit", domain.AIModel{ContextLength: 100_000, MaxOutputTokens: 4000}, 1000, 4000},
+		{"rest of the context", domain.AIModel{ContextLength: 10_000, MaxOutputTokens: 8000}, 5000, 5000},
+		{"context only", domain.AIModel{ContextLength: 10_000}, 4000, 6000},
+		{"prompt fills the context", domain.AIModel{ContextLength: 1000}, 2000, 0},
+		{"output limit only", domain.AIModel{MaxOutputTokens: 4000}, 1000, 4000},
+		{"unknown", domain.AIModel{}, 1000, config.ContextCompletionReserve},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaxCompletionTokens(&tt.model, tt.prompt); got != tt.want {
+				t.Errorf("MaxCompletionTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCostEstimateTokensWithin(t *testing.T) {
+	// $1 per 1M prompt tokens and $10 per 1M completion tokens, no markup
+	model := &domain.AIModel{PromptPrice: 1, CompletionPrice: 10, ContextLength: 200_000, MaxOutputTokens: 50_000}
+	messages := []ChatMessage{{Role: "user", Content: "hello"}}
+	estimate := EstimateCost(model, messages, 0, 0)
+
+	if estimate.MaxCompletionTokens != 50_000 {
+		t.Fatalf("MaxCompletionTokens = %d, want 50000", estimate.MaxCompletionTokens)
+	}
+	if !estimate.Max.GreaterThan(estimate.Min) {
+		t.Fatalf("Max %s is not above Min %s", estimate.Max, estimate.Min)
+	}
+
+	tests := []struct {
+		name  string
+		limit decimal.Decimal
+		want  int
+	}{
+		{"below the prompt", estimate.Min.Div(decimal.NewFromInt(2)), 0},
+		{"prompt only", estimate.Min, 0},
+		{"some of the answer", estimate.Min.Add(decimal.RequireFromString("0.01")), 1000},
+		{"whole answer", estimate.Max.Add(decimal.NewFromInt(1)), 50_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimate.TokensWithin(tt.limit); got != tt.want {
+				t.Errorf("TokensWithin(%s) = %d, want %d", tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCostEstimateLimitCompletion(t *testing.T) {
+	// $1 per 1M prompt tokens and $10 per 1M completion tokens, no markup
+	model := &domain.AIModel{PromptPrice: 1, CompletionPrice: 10, ContextLength: 200_000, MaxOutputTokens: 50_000}
+	estimate := EstimateCost(model, []ChatMessage{{Role: "user", Content: "hello"}}, 0, 0)
+
+	limited := estimate.LimitCompletion(1000)
+	if limited.MaxCompletionTokens != 1000 {
+		t.Errorf("MaxCompletionTokens = %d, want 1000", limited.MaxCompletionTokens)
+	}
+	if want := estimate.Min.Add(decimal.RequireFromString("0.01")); !limited.Max.Equal(want) {
+		t.Errorf("Max = %s, want %s", limited.Max, want)
+	}
+	if got := limited.TokensWithin(estimate.Max); got != 1000 {
+		t.Errorf("TokensWithin() = %d, want 1000", got)
+	}
+
+	if got := estimate.LimitCompletion(100_000); got.MaxCompletionTokens != 50_000 || !got.Max.Equal(estimate.Max) {
+		t.Errorf("LimitCompletion above the model limit changed the estimate: %+v", got)
+	}
+}
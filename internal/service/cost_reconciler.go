@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+	"github.com/shopspring/decimal"
+)
+
+// GenerationCharge is a charge for an AI answer, recorded for reconciliation.
+// Exactly one of UserID and GroupID is set.
+type GenerationCharge struct {
+	UserID        *int64
+	GroupID       *int64
+	Model         *domain.AIModel
+	Usage         ChatUsage
+	Charged       decimal.Decimal // taken from the balance, with markup
+	MarkupPercent float64
+}
+
+// CostReconciler checks what was charged for AI answers against the cost
+// OpenRouter reports for their generations. Charges are estimates when the
+// response carries no cost, so the difference is corrected on the balance
+// once the provider's stats are in.
+type CostReconciler struct {
+	db         *pgxpool.Pool
+	queries    *sqlc.Queries
+	openRouter *OpenRouterService
+}
+
+func NewCostReconciler(db *pgxpool.Pool, queries *sqlc.Queries, openRouter *OpenRouterService) *CostReconciler {
+	return &CostReconciler{db: db, queries: queries, openRouter: openRouter}
+}
+
+// Track records a charge to be reconciled later. Only answers of OpenRouter
+// models can be looked up; other charges are ignored.
+func (r *CostReconciler) Track(ctx context.Context, c GenerationCharge) error {
+	if c.Model.Provider != ProviderOpenRouter || len(c.Usage.GenerationIDs) == 0 || !c.Charged.IsPositive() {
+		return nil
+	}
+	markup := decimal.NewFromFloat(1 + c.MarkupPercent/100)
+	return r.queries.CreateGenerationCost(ctx, sqlc.CreateGenerationCostParams{
+		UserID:        c.UserID,
+		GroupID:       c.GroupID,
+		Model:         c.Model.ID,
+		GenerationIds: c.Usage.GenerationIDs,
+		MarkupPercent: c.MarkupPercent,
+		EstimatedCost: c.Charged.Div(markup),
+		Charged:       c.Charged,
+	})
+}
+
+// Reconcile settles a batch of charges old enough for their stats to be
+// available. A charge whose stats cannot be fetched is retried on the next
+// run, up to config.CostReconcileMaxAttempts times.
+func (r *CostReconciler) Reconcile(ctx context.Context) error {
+	pending, err := r.queries.ListPendingGenerationCosts(ctx, sqlc.ListPendingGenerationCostsParams{
+		CreatedAt: pgtype.Timestamptz{Time: time.Now().Add(-config.CostReconcileDelay), Valid: true},
+		Limit:     config.CostReconcileBatch,
+	})
+	if err != nil {
+		return fmt.Errorf("list pending charges: %w", err)
+	}
+
+	for _, c := range pending {
+		actual, err := r.actualCost(ctx, c.GenerationIds)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Error("fetch generation cost", "charge", c.ID, "error", err)
+			if err := r.queries.RetryGenerationCost(ctx, sqlc.RetryGenerationCostParams{
+				MaxAttempts: config.CostReconcileMaxAttempts,
+				ID:          c.ID,
+			}); err != nil {
+				return fmt.Errorf("retry charge %d: %w", c.ID, err)
+			}
+			continue
+		}
+		if err := r.settle(ctx, c, actual); err != nil {
+			return fmt.Errorf("settle charge %d: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+// actualCost sums the provider's cost of the generations, without markup.
+func (r *CostReconciler) actualCost(ctx context.Context, ids []string) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, id := range ids {
+		cost, err := r.openRouter.GenerationCost(ctx, id)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("generation %s: %w", id, err)
+		}
+		total = total.Add(decimal.NewFromFloat(cost))
+	}
+	return total, nil
+}
+
+// settle charges or refunds the difference between the actual cost with
+// markup and what was charged, and marks the charge as reconciled. Extra
+// charges may take the balance below zero: the answer was already given.
+func (r *CostReconciler) settle(ctx context.Context, c sqlc.GenerationCost, actual decimal.Decimal) error {
+	markup := decimal.NewFromFloat(1 + c.MarkupPercent/100)
+	correction := actual.Mul(markup).Sub(c.Charged).Round(10)
+	if correction.Abs().LessThan(decimal.NewFromFloat(config.CostCorrectionThreshold)) {
+		correction = decimal.Zero
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := r.queries.WithTx(tx)
+
+	if !correction.IsZero() {
+		amount := correction.Neg()
+		if c.UserID != nil {
+			_, err = qtx.UpdateUserBalance(ctx, sqlc.UpdateUserBalanceParams{ID: *c.UserID, Balance: amount})
+		} else if c.GroupID != nil {
+			_, err = qtx.UpdateGroupBalance(ctx, sqlc.UpdateGroupBalanceParams{ID: *c.GroupID, Balance: amount})
+		}
+		if err != nil {
+			return fmt.Errorf("update balance: %w", err)
+		}
+
+		txType := domain.TxTypeDebit
+		if correction.IsNegative() {
+			txType = domain.TxTypeCredit
+		}
+		if _, err := qtx.CreateTransaction(ctx, sqlc.CreateTransactionParams{
+			UserID:      c.UserID,
+			GroupID:     c.GroupID,
+			Amount:      amount,
+			TxType:      string(txType),
+			Description: fmt.Sprintf("Cost correction: %s", c.Model),
+		}); err != nil {
+			return fmt.Errorf("create transaction: %w", err)
+		}
+	}
+
+	if err := qtx.ReconcileGenerationCost(ctx, sqlc.ReconcileGenerationCostParams{
+		ID:         c.ID,
+		ActualCost: actual,
+		Correction: correction,
+	}); err != nil {
+		return fmt.Errorf("mark reconciled: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
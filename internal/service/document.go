@@ -0,0 +1,223 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+const (
+	mimePDF  = "application/pdf"
+	mimeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// textExtensions are plain text, markup, data and source code files.
+var textExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".rst": true, ".log": true,
+	".csv": true, ".tsv": true, ".json": true, ".jsonl": true, ".xml": true,
+	".yaml": true, ".yml": true, ".toml": true, ".ini": true, ".cfg": true,
+	".conf": true, ".env": true, ".sql": true, ".html": true, ".htm": true,
+	".css": true, ".scss": true, ".go": true, ".py": true, ".js": true,
+	".mjs": true, ".ts": true, ".jsx": true, ".tsx": true, ".vue": true,
+	".svelte": true, ".java": true, ".kt": true, ".kts": true, ".scala": true,
+	".c": true, ".h": true, ".cpp": true, ".hpp": true, ".cc": true,
+	".cs": true, ".rb": true, ".php": true, ".rs": true, ".swift": true,
+	".dart": true, ".lua": true, ".r": true, ".pl": true, ".sh": true,
+	".bash": true, ".zsh": true, ".ps1": true, ".bat": true, ".tex": true,
+	".proto": true, ".graphql": true, ".dockerfile": true, ".tf": true,
+}
+
+// textMIMETypes are non-text/* MIME types that still carry plain text.
+var textMIMETypes = map[string]bool{
+	"application/json":        true,
+	"application/xml":         true,
+	"application/javascript":  true,
+	"application/x-sh":        true,
+	"application/x-yaml":      true,
+	"application/sql":         true,
+	"application/x-httpd-php": true,
+}
+
+// Document is a file sent by the user, reduced to text for the model.
+type Document struct {
+	Name      string
+	MIMEType  string
+	Text      string
+	Truncated bool   // text was cut to config.MaxDocumentChars
+	PDF       []byte // original file of a PDF without a text layer
+}
+
+// IsImageDocument reports whether a file sent as a document is an image and
+// should go to the model as a picture.
+func IsImageDocument(name, mimeType string) bool {
+	if strings.HasPrefix(mimeType, "image/") {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif":
+		return true
+	}
+	return false
+}
+
+// ExtractDocument detects the type of a file and extracts its text. It returns
+// domain.ErrUnsupportedDocument for formats it cannot read and
+// domain.ErrEmptyDocument when no text was found. A PDF without a text layer
+// is returned with an empty Text and the file in PDF.
+func ExtractDocument(name, mimeType string, data []byte) (*Document, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	sniffed := http.DetectContentType(data)
+	doc := &Document{Name: name, MIMEType: mimeType}
+
+	var text string
+	switch {
+	case ext == ".pdf" || mimeType == mimePDF || bytes.HasPrefix(data, []byte("%PDF-")):
+		doc.MIMEType = mimePDF
+		text = extractPDFText(data)
+		if !hasReadableText(text) {
+			doc.PDF = data
+			return doc, nil
+		}
+	case ext == ".docx" || mimeType == mimeDOCX:
+		doc.MIMEType = mimeDOCX
+		t, err := extractDOCXText(data)
+		if err != nil {
+			return nil, err
+		}
+		text = t
+	case textExtensions[ext] || strings.HasPrefix(mimeType, "text/") || textMIMETypes[mimeType] ||
+		(ext == "" && strings.HasPrefix(sniffed, "text/plain")):
+		if bytes.IndexByte(data, 0) >= 0 {
+			return nil, domain.ErrUnsupportedDocument
+		}
+		text = strings.ToValidUTF8(strings.TrimPrefix(string(data), "\uFEFF"), "\uFFFD")
+	default:
+		return nil, domain.ErrUnsupportedDocument
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, domain.ErrEmptyDocument
+	}
+	if utf8.RuneCountInString(text) > config.MaxDocumentChars {
+		text = string([]rune(text)[:config.MaxDocumentChars])
+		doc.Truncated = true
+	}
+	doc.Text = text
+	return doc, nil
+}
+
+// Prompt combines the user's message with the document text.
+func (d *Document) Prompt(userText string) string {
+	var sb strings.Builder
+	if userText != "" {
+		sb.WriteString(userText + "\n\n")
+	}
+	if d.Text == "" {
+		sb.WriteString(fmt.Sprintf("[File: %s]", d.Name))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("<file name=%q>\n%s\n</file>", d.Name, d.Text))
+	if d.Truncated {
+		sb.WriteString(fmt.Sprintf("\n[The file was truncated to the first %d characters]", config.MaxDocumentChars))
+	}
+	return sb.String()
+}
+
+// FileContentPart returns the PDF as a file content part. OpenRouter parses
+// such files itself, so it works for PDFs whose text could not be extracted.
+func (d *Document) FileContentPart() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "file",
+		"file": map[string]string{
+			"filename":  d.Name,
+			"file_data": "data:" + mimePDF + ";base64," + base64.StdEncoding.EncodeToString(d.PDF),
+		},
+	}
+}
+
+// hasReadableText reports whether extracted text looks like words rather than
+// glyph codes of fonts without a Unicode mapping. Unmapped codes come out as
+// Latin-1, so only ASCII letters and digits and letters beyond Latin-1 count.
+func hasReadableText(text string) bool {
+	var letters, total int
+	for _, r := range text {
+		if r == ' ' || r == '\n' || r == '\t' {
+			continue
+		}
+		total++
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) || r > 0xFF && unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters >= 20 && letters*2 >= total
+}
+
+// extractDOCXText reads the paragraphs of word/document.xml.
+func extractDOCXText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", domain.ErrUnsupportedDocument
+	}
+
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("open document.xml: %w", err)
+		}
+		defer rc.Close()
+
+		var sb strings.Builder
+		dec := xml.NewDecoder(io.LimitReader(rc, config.MaxDocumentInflated))
+		inText := false
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("parse document.xml: %w", err)
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "t":
+					inText = true
+				case "tab":
+					sb.WriteString("\t")
+				case "br", "cr":
+					sb.WriteString("\n")
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "t":
+					inText = false
+				case "p":
+					sb.WriteString("\n")
+				case "tc":
+					sb.WriteString("\t")
+				}
+			case xml.CharData:
+				if inText {
+					sb.Write(t)
+				}
+			}
+		}
+		return sb.String(), nil
+	}
+	return "", domain.ErrUnsupportedDocument
+}
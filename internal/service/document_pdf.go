@@ -0,0 +1,351 @@
+package service
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/set-night/mindapp/internal/config"
+)
+
+var (
+	pdfStreamStart = regexp.MustCompile(`stream\r?\n`)
+	pdfBFChar      = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>`)
+	pdfBFRange     = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>\s*(<[0-9A-Fa-f]+>|\[[^\]]*\])`)
+	pdfHexString   = regexp.MustCompile(`<([0-9A-Fa-f]+)>`)
+)
+
+// extractPDFText pulls the text out of a PDF's content streams. It handles
+// Flate-compressed streams and ToUnicode maps, which covers documents exported
+// from office suites; scanned PDFs and exotic encodings yield little or no text.
+func extractPDFText(data []byte) string {
+	streams := pdfStreams(data, config.MaxDocumentInflated)
+
+	// ToUnicode maps of all fonts are merged: fonts in one document rarely
+	// assign the same code to different characters
+	cmap := make(map[string]string)
+	for _, s := range streams {
+		if bytes.Contains(s, []byte("begincmap")) {
+			parseToUnicode(s, cmap)
+		}
+	}
+
+	var sb strings.Builder
+	for _, s := range streams {
+		if bytes.Contains(s, []byte("BT")) && !bytes.Contains(s, []byte("begincmap")) {
+			extractContentText(s, cmap, &sb)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// pdfStreams returns the decoded stream bodies, skipping images and fonts.
+// Decoding stops once limit bytes have been inflated, so that a small file of
+// compressed zeros cannot exhaust memory.
+func pdfStreams(data []byte, limit int64) [][]byte {
+	var streams [][]byte
+	remaining := limit
+	next := 0 // end of the previous stream; matches before it are its data or "endstream"
+	for _, loc := range pdfStreamStart.FindAllIndex(data, -1) {
+		if remaining <= 0 {
+			break
+		}
+		if loc[0] < next {
+			continue
+		}
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		body := data[start : start+end]
+		next = start + end + len("endstream")
+
+		// The stream dictionary precedes the keyword
+		dictStart := bytes.LastIndex(data[:loc[0]], []byte("obj"))
+		if dictStart < 0 {
+			continue
+		}
+		dict := string(data[dictStart:loc[0]])
+		if strings.Contains(dict, "/Image") || strings.Contains(dict, "/FontFile") ||
+			strings.Contains(dict, "/Length1") || strings.Contains(dict, "/XRef") {
+			continue
+		}
+
+		switch {
+		case strings.Contains(dict, "/FlateDecode"):
+			r, err := zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				continue
+			}
+			decoded, _ := io.ReadAll(io.LimitReader(r, remaining)) // keep what was inflated before any error
+			r.Close()
+			remaining -= int64(len(decoded))
+			streams = append(streams, decoded)
+		case !strings.Contains(dict, "/Filter"):
+			streams = append(streams, body)
+		}
+	}
+	return streams
+}
+
+// parseToUnicode reads bfchar and bfrange entries of a ToUnicode CMap.
+func parseToUnicode(s []byte, cmap map[string]string) {
+	text := string(s)
+	for _, section := range pdfSections(text, "beginbfchar", "endbfchar") {
+		for _, m := range pdfBFChar.FindAllStringSubmatch(section, -1) {
+			cmap[strings.ToUpper(m[1])] = utf16HexToString(m[2])
+		}
+	}
+	for _, section := range pdfSections(text, "beginbfrange", "endbfrange") {
+		for _, m := range pdfBFRange.FindAllStringSubmatch(section, -1) {
+			lo, err1 := strconv.ParseUint(m[1], 16, 32)
+			hi, err2 := strconv.ParseUint(m[2], 16, 32)
+			if err1 != nil || err2 != nil || hi < lo || hi-lo > 0xFFFF {
+				continue
+			}
+			width := len(m[1])
+			if strings.HasPrefix(m[3], "[") {
+				dests := pdfHexString.FindAllStringSubmatch(m[3], -1)
+				for i, d := range dests {
+					if lo+uint64(i) > hi {
+						break
+					}
+					cmap[pdfCode(lo+uint64(i), width)] = utf16HexToString(d[1])
+				}
+				continue
+			}
+			dst := []rune(utf16HexToString(strings.Trim(m[3], "<>")))
+			if len(dst) == 0 {
+				continue
+			}
+			for code := lo; code <= hi; code++ {
+				r := dst[len(dst)-1] + rune(code-lo)
+				cmap[pdfCode(code, width)] = string(dst[:len(dst)-1]) + string(r)
+			}
+		}
+	}
+}
+
+func pdfSections(text, begin, end string) []string {
+	var sections []string
+	for {
+		i := strings.Index(text, begin)
+		if i < 0 {
+			return sections
+		}
+		text = text[i+len(begin):]
+		j := strings.Index(text, end)
+		if j < 0 {
+			return append(sections, text)
+		}
+		sections = append(sections, text[:j])
+		text = text[j+len(end):]
+	}
+}
+
+func pdfCode(code uint64, width int) string {
+	s := strings.ToUpper(strconv.FormatUint(code, 16))
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
+
+func utf16HexToString(h string) string {
+	raw, err := hex.DecodeString(h)
+	if err != nil || len(raw)%2 != 0 {
+		return ""
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+	}
+	return string(utf16.Decode(units))
+}
+
+// extractContentText interprets the text operators of a content stream.
+func extractContentText(s []byte, cmap map[string]string, sb *strings.Builder) {
+	var operands []string // decoded strings since the last operator
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '(':
+			str, next := pdfLiteralString(s, i)
+			operands = append(operands, decodePDFString(str, cmap))
+			i = next
+		case c == '<' && i+1 < len(s) && s[i+1] != '<':
+			end := bytes.IndexByte(s[i:], '>')
+			if end < 0 {
+				return
+			}
+			raw, _ := hex.DecodeString(strings.Join(strings.Fields(string(s[i+1:i+end])), ""))
+			operands = append(operands, decodePDFString(raw, cmap))
+			i += end + 1
+		case c == '%':
+			for i < len(s) && s[i] != '\n' && s[i] != '\r' {
+				i++
+			}
+		case c == '[':
+			// TJ array: strings interleaved with kerning; big gaps are spaces
+			operands = operands[:0]
+			i++
+			for i < len(s) && s[i] != ']' {
+				switch {
+				case s[i] == '(':
+					str, next := pdfLiteralString(s, i)
+					operands = append(operands, decodePDFString(str, cmap))
+					i = next
+				case s[i] == '<':
+					end := bytes.IndexByte(s[i:], '>')
+					if end < 0 {
+						return
+					}
+					raw, _ := hex.DecodeString(strings.Join(strings.Fields(string(s[i+1:i+end])), ""))
+					operands = append(operands, decodePDFString(raw, cmap))
+					i += end + 1
+				case s[i] == '-' || s[i] == '.' || (s[i] >= '0' && s[i] <= '9'):
+					j := i + 1
+					for j < len(s) && (s[j] == '.' || (s[j] >= '0' && s[j] <= '9')) {
+						j++
+					}
+					if v, err := strconv.ParseFloat(string(s[i:j]), 64); err == nil && v < -200 && len(operands) > 0 {
+						operands[len(operands)-1] += " "
+					}
+					i = j
+				default:
+					i++
+				}
+			}
+			i++
+		case isPDFLetter(c):
+			j := i
+			for j < len(s) && (isPDFLetter(s[j]) || s[j] == '*' || s[j] == '\'' || s[j] == '"') {
+				j++
+			}
+			if j == i {
+				j++
+			}
+			switch string(s[i:j]) {
+			case "Tj", "TJ":
+				for _, o := range operands {
+					sb.WriteString(o)
+				}
+			case "'", "\"":
+				sb.WriteString("\n")
+				for _, o := range operands {
+					sb.WriteString(o)
+				}
+			case "T*", "Td", "TD", "ET":
+				if !strings.HasSuffix(sb.String(), "\n") {
+					sb.WriteString("\n")
+				}
+			case "Tm":
+				sb.WriteString(" ")
+			}
+			operands = operands[:0]
+			i = j
+		case c == '\'' || c == '"':
+			sb.WriteString("\n")
+			for _, o := range operands {
+				sb.WriteString(o)
+			}
+			operands = operands[:0]
+			i++
+		default:
+			i++
+		}
+	}
+}
+
+func isPDFLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// pdfLiteralString reads a (...) string starting at s[i] with nesting and
+// escapes resolved, and returns it with the index after the closing paren.
+func pdfLiteralString(s []byte, i int) ([]byte, int) {
+	var out []byte
+	depth := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch e := s[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					j := i
+					for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+						j++
+					}
+					v, _ := strconv.ParseUint(string(s[i:j]), 8, 8)
+					out = append(out, byte(v))
+					i = j - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+		case c == '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return out, i + 1
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+		i++
+	}
+	return out, i
+}
+
+// decodePDFString maps string bytes to text through the ToUnicode map when the
+// codes are known, otherwise reads them as Latin-1.
+func decodePDFString(raw []byte, cmap map[string]string) string {
+	if len(cmap) > 0 {
+		for _, width := range []int{2, 1} {
+			if len(raw)%width != 0 {
+				continue
+			}
+			var sb strings.Builder
+			ok := true
+			for i := 0; i < len(raw); i += width {
+				r, found := cmap[strings.ToUpper(hex.EncodeToString(raw[i:i+width]))]
+				if !found {
+					ok = false
+					break
+				}
+				sb.WriteString(r)
+			}
+			if ok {
+				return sb.String()
+			}
+		}
+	}
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+// pdfStream builds an indirect object with a stream, as it appears in a PDF.
+func pdfStream(num int, dict, body string) string {
+	return fmt.Sprintf("%d 0 obj\n<< %s /Length %d >>\nstream\n%s\nendstream\nendobj\n", num, dict, len(body), body)
+}
+
+func deflate(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func pdfFile(objects ...string) []byte {
+	return []byte("%PDF-1.7\n" + strings.Join(objects, "") + "trailer\n<< >>\n%%EOF\n")
+}
+
+func TestExtractPDFText(t *testing.T) {
+	const cmap = "/CIDInit /ProcSet findresource begin\nbegincmap\n" +
+		"2 beginbfchar\n<01> <0041>\n<02> <0431>\nendbfchar\n" +
+		"1 beginbfrange\n<03> <05> <0061>\nendbfrange\n" +
+		"endcmap\nend"
+
+	tests := []struct {
+		name string
+		pdf  func(t *testing.T) []byte
+		want string
+	}{
+		{
+			name: "uncompressed literal strings",
+			pdf: func(t *testing.T) []byte {
+				return pdfFile(pdfStream(1, "", "BT /F1 12 Tf (Hello, world) Tj T* (Second line) Tj ET"))
+			},
+			want: "Hello, world\nSecond line",
+		},
+		{
+			name: "flate stream with escapes",
+			pdf: func(t *testing.T) []byte {
+				return pdfFile(pdfStream(1, "/Filter /FlateDecode", deflate(t, `BT (Price \(net\): 5\0451) Tj ET`)))
+			},
+			want: "Price (net): 5%1",
+		},
+		{
+			name: "kerned TJ array",
+			pdf: func(t *testing.T) []byte {
+				return pdfFile(pdfStream(1, "", "BT [(Hel) -20 (lo) -500 (there)] TJ ET"))
+			},
+			want: "Hello there",
+		},
+		{
+			name: "ToUnicode map",
+			pdf: func(t *testing.T) []byte {
+				return pdfFile(
+					pdfStream(1, "", cmap),
+					pdfStream(2, "/Filter /FlateDecode", deflate(t, "BT <0102> Tj <030405> Tj ET")),
+				)
+			},
+			want: "Aбabc",
+		},
+		{
+			name: "images, fonts and unknown filters are skipped",
+			pdf: func(t *testing.T) []byte {
+				return pdfFile(
+					pdfStream(1, "/Subtype /Image", "BT (image) Tj ET"),
+					pdfStream(2, "/Length1 100", "BT (font) Tj ET"),
+					pdfStream(3, "/Filter /DCTDecode", "BT (jpeg) Tj ET"),
+					pdfStream(4, "", "BT (text) Tj ET"),
+				)
+			},
+			want: "text",
+		},
+		{
+			name: "no text layer",
+			pdf: func(t *testing.T) []byte {
+				return pdfFile(pdfStream(1, "/Subtype /Image /Filter /FlateDecode", deflate(t, strings.Repeat("\x00", 64))))
+			},
+			want: "",
+		},
+		{
+			name: "broken flate stream",
+			pdf: func(t *testing.T) []byte {
+				return pdfFile(pdfStream(1, "/Filter /FlateDecode", "not zlib"), pdfStream(2, "", "BT (ok) Tj ET"))
+			},
+			want: "ok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractPDFText(tt.pdf(t)); got != tt.want {
+				t.Errorf("extractPDFText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFStreamsInflateLimit(t *testing.T) {
+	zeros := deflate(t, strings.Repeat("\x00", 1000))
+	data := pdfFile(
+		pdfStream(1, "/Filter /FlateDecode", zeros),
+		pdfStream(2, "/Filter /FlateDecode", zeros),
+		pdfStream(3, "/Filter /FlateDecode", zeros),
+	)
+
+	streams := pdfStreams(data, 1500)
+	if len(streams) != 2 {
+		t.Fatalf("decoded %d streams, want 2", len(streams))
+	}
+	if len(streams[0]) != 1000 || len(streams[1]) != 500 {
+		t.Errorf("stream sizes = %d, %d, want 1000, 500", len(streams[0]), len(streams[1]))
+	}
+}
+
+func docxFile(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractDOCXText(t *testing.T) {
+	const body = `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Заголовок</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">Первая </w:t></w:r><w:r><w:t>строка</w:t><w:br/><w:t>вторая</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>A</w:t><w:tab/><w:t>1</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>B</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+<w:p><w:pPr><w:rPr><w:b/></w:rPr></w:pPr></w:p>
+</w:body></w:document>`
+
+	tests := []struct {
+		name    string
+		data    func(t *testing.T) []byte
+		want    string
+		wantErr error
+	}{
+		{
+			name: "paragraphs, breaks and tables",
+			data: func(t *testing.T) []byte {
+				return docxFile(t, map[string]string{"word/document.xml": body, "word/styles.xml": "<w:styles/>"})
+			},
+			want: "Заголовок\nПервая строка\nвторая\nA\t1\n\tB\n\t\n",
+		},
+		{
+			name:    "not a zip",
+			data:    func(t *testing.T) []byte { return []byte("plain text") },
+			wantErr: domain.ErrUnsupportedDocument,
+		},
+		{
+			name: "zip without a document",
+			data: func(t *testing.T) []byte {
+				return docxFile(t, map[string]string{"xl/workbook.xml": "<workbook/>"})
+			},
+			wantErr: domain.ErrUnsupportedDocument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractDOCXText(tt.data(t))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("extractDOCXText() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("extractDOCXText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
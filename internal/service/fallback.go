@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+)
+
+// FallbackService retries failed chat requests on equivalent models. Chains are
+// configured per model by admins; free models without a configured chain fall
+// back to other free models from the catalogue.
+type FallbackService struct {
+	queries *sqlc.Queries
+	llm     *LLMRouter
+}
+
+func NewFallbackService(queries *sqlc.Queries, llm *LLMRouter) *FallbackService {
+	return &FallbackService{queries: queries, llm: llm}
+}
+
+// Chain returns the models to try after the given one, in order.
+func (s *FallbackService) Chain(ctx context.Context, model *domain.AIModel) ([]string, error) {
+	chain, err := s.queries.GetModelFallbacks(ctx, model.ID)
+	if err == nil {
+		return chain, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("get fallbacks: %w", err)
+	}
+	if !model.IsFree() {
+		return nil, nil
+	}
+
+	// Default chain: the free models with the largest context windows that
+	// can handle the same input and tools
+	all, err := s.llm.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var free []domain.AIModel
+	for _, m := range all {
+		if !m.IsFree() || m.ID == model.ID {
+			continue
+		}
+		if !canReplace(&m, model) {
+			continue
+		}
+		free = append(free, m)
+	}
+	sort.Slice(free, func(i, j int) bool {
+		return free[i].ContextLength > free[j].ContextLength
+	})
+	for i := 0; i < len(free) && i < config.DefaultFreeFallbacks; i++ {
+		chain = append(chain, free[i].ID)
+	}
+	return chain, nil
+}
+
+// canReplace reports whether fallback can handle the input and tools of
+// requests meant for model.
+func canReplace(fallback, model *domain.AIModel) bool {
+	need, have := model.Capabilities, fallback.Capabilities
+	return (!need.Vision || have.Vision) && (!need.Audio || have.Audio) && (!need.Tools || have.Tools)
+}
+
+func (s *FallbackService) Set(ctx context.Context, modelID string, chain []string) error {
+	return s.queries.SetModelFallbacks(ctx, sqlc.SetModelFallbacksParams{
+		ModelID:        modelID,
+		FallbackModels: chain,
+	})
+}
+
+func (s *FallbackService) Clear(ctx context.Context, modelID string) error {
+	return s.queries.DeleteModelFallbacks(ctx, modelID)
+}
+
+func (s *FallbackService) List(ctx context.Context) ([]sqlc.ModelFallback, error) {
+	return s.queries.ListModelFallbacks(ctx)
+}
+
+// Chat sends the request to req.Model and, if it is rate limited or unavailable,
+// to each model of its fallback chain. It returns the model that answered. A
+// slow answer is not switched: without streaming there is no sign of progress
+// before the whole answer arrives.
+func (s *FallbackService) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, *domain.AIModel, error) {
+	return s.run(ctx, req, 0, func(attemptCtx context.Context, req ChatRequest, _ func()) (*ChatResponse, error) {
+		return s.llm.Chat(attemptCtx, req)
+	})
+}
+
+// ChatStream is the streaming variant of Chat. A model that sends no text
+// within config.FallbackAttemptTimeout is switched too; once any text has been
+// streamed the request is no longer switched to another model.
+func (s *FallbackService) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string)) (*ChatResponse, *domain.AIModel, error) {
+	var streamed atomic.Bool
+	return s.run(ctx, req, config.FallbackAttemptTimeout, func(attemptCtx context.Context, req ChatRequest, firstToken func()) (*ChatResponse, error) {
+		resp, err := s.llm.ChatStream(attemptCtx, req, func(delta string) {
+			firstToken()
+			streamed.Store(true)
+			if onDelta != nil {
+				onDelta(delta)
+			}
+		})
+		if err != nil && streamed.Load() {
+			return nil, errNoFallback{err}
+		}
+		return resp, err
+	})
+}
+
+// errNoFallback marks an error after which the request must not be retried.
+type errNoFallback struct{ error }
+
+func (e errNoFallback) Unwrap() error { return e.error }
+
+type attemptFunc func(ctx context.Context, req ChatRequest, firstToken func()) (*ChatResponse, error)
+
+// run tries the candidates in turn. With a firstTokenTimeout, every attempt
+// except the last must produce a first token within it.
+func (s *FallbackService) run(ctx context.Context, req ChatRequest, firstTokenTimeout time.Duration, attempt attemptFunc) (*ChatResponse, *domain.AIModel, error) {
+	model, err := s.llm.GetModel(ctx, req.Model)
+	if err != nil {
+		return nil, nil, err
+	}
+	chain, err := s.Chain(ctx, model)
+	if err != nil {
+		slog.Error("get fallback chain", "error", err, "model", model.ID)
+	}
+
+	candidates := []*domain.AIModel{model}
+	for _, modelID := range chain {
+		m, err := s.llm.GetModel(ctx, modelID)
+		if err != nil {
+			slog.Warn("skip unknown fallback model", "model", modelID)
+			continue
+		}
+		// Admin chains are not checked when set, and models change
+		if !canReplace(m, model) {
+			slog.Warn("skip incapable fallback model", "model", modelID, "for", model.ID)
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+
+	for i, current := range candidates {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		stopTimer := func() bool { return true }
+		if firstTokenTimeout > 0 && i < len(candidates)-1 {
+			timer := time.AfterFunc(firstTokenTimeout, cancel)
+			stopTimer = timer.Stop
+		}
+
+		req.Model = current.ID
+		resp, attemptErr := attempt(attemptCtx, req, func() { stopTimer() })
+		timedOut := attemptCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+
+		if attemptErr == nil {
+			return resp, current, nil
+		}
+		err = attemptErr
+		if !isFallbackError(attemptErr) && !timedOut {
+			break
+		}
+		slog.Warn("model failed, trying fallback", "model", current.ID, "error", attemptErr)
+	}
+	return nil, nil, err
+}
+
+func isFallbackError(err error) bool {
+	var noFallback errNoFallback
+	if errors.As(err, &noFallback) {
+		return false
+	}
+	return errors.Is(err, domain.ErrRateLimited) || errors.Is(err, domain.ErrServiceUnavailable) ||
+		errors.Is(err, domain.ErrCircuitOpen)
+}
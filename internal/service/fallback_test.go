@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+func TestCanReplace(t *testing.T) {
+	caps := func(c domain.ModelCapabilities) *domain.AIModel { return &domain.AIModel{Capabilities: c} }
+	all := domain.ModelCapabilities{Vision: true, Audio: true, Tools: true}
+
+	tests := []struct {
+		name     string
+		fallback *domain.AIModel
+		model    *domain.AIModel
+		want     bool
+	}{
+		{"text model", caps(domain.ModelCapabilities{}), caps(domain.ModelCapabilities{}), true},
+		{"more capable fallback", caps(all), caps(domain.ModelCapabilities{Vision: true}), true},
+		{"no vision", caps(domain.ModelCapabilities{Tools: true}), caps(domain.ModelCapabilities{Vision: true}), false},
+		{"no audio", caps(domain.ModelCapabilities{Vision: true}), caps(domain.ModelCapabilities{Audio: true}), false},
+		{"no tools", caps(domain.ModelCapabilities{Vision: true, Audio: true}), caps(all), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canReplace(tt.fallback, tt.model); got != tt.want {
+				t.Errorf("canReplace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+// LLMProvider is a backend that serves chat models.
+type LLMProvider interface {
+	// Name identifies the provider and is stored in domain.AIModel.Provider.
+	Name() string
+	ListModels(ctx context.Context) ([]domain.AIModel, error)
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string)) (*ChatResponse, error)
+}
+
+// LLMRouter routes each chat request to the provider that serves the
+// requested model, as listed in the model catalogue. Transient upstream
+// failures are retried with backoff, and models that keep failing are blocked
+// by the circuit breaker.
+type LLMRouter struct {
+	catalog *ModelCatalog
+	breaker *CircuitBreaker
+}
+
+func NewLLMRouter(catalog *ModelCatalog, breaker *CircuitBreaker) *LLMRouter {
+	return &LLMRouter{catalog: catalog, breaker: breaker}
+}
+
+// ListModels returns the merged catalogue of all providers.
+func (r *LLMRouter) ListModels(ctx context.Context) ([]domain.AIModel, error) {
+	return r.catalog.Models(ctx)
+}
+
+func (r *LLMRouter) GetModel(ctx context.Context, modelID string) (*domain.AIModel, error) {
+	models, err := r.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range models {
+		if m.ID == modelID {
+			return &m, nil
+		}
+	}
+	return nil, domain.ErrModelNotFound
+}
+
+func (r *LLMRouter) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	p, model, err := r.providerFor(ctx, req.Model)
+	if err != nil {
+		return nil, err
+	}
+	req = cacheBreakpoints(model, supportedParams(model, req))
+	return r.withRetry(ctx, req.Model, func() (*ChatResponse, bool, error) {
+		resp, err := p.Chat(ctx, req)
+		return resp, false, err
+	})
+}
+
+func (r *LLMRouter) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string)) (*ChatResponse, error) {
+	p, model, err := r.providerFor(ctx, req.Model)
+	if err != nil {
+		return nil, err
+	}
+	req = cacheBreakpoints(model, supportedParams(model, req))
+	return r.withRetry(ctx, req.Model, func() (*ChatResponse, bool, error) {
+		started := false
+		resp, err := p.ChatStream(ctx, req, func(delta string) {
+			started = true
+			if onDelta != nil {
+				onDelta(delta)
+			}
+		})
+		return resp, started, err
+	})
+}
+
+// Circuits returns the state of all models with recent upstream failures.
+func (r *LLMRouter) Circuits() []CircuitStatus {
+	return r.breaker.Snapshot()
+}
+
+// ResetCircuit re-enables a model blocked by the circuit breaker.
+func (r *LLMRouter) ResetCircuit(modelID string) {
+	r.breaker.Reset(modelID)
+}
+
+// withRetry runs attempt until it succeeds, fails permanently or runs out of
+// retries. An attempt that already streamed text to the user is never repeated.
+// Every exit reports to the circuit breaker, so that a probe of a half-open
+// circuit is never left pending.
+func (r *LLMRouter) withRetry(ctx context.Context, modelID string, attempt func() (*ChatResponse, bool, error)) (*ChatResponse, error) {
+	if err := r.breaker.Allow(modelID); err != nil {
+		return nil, err
+	}
+
+	for try := 0; ; try++ {
+		resp, started, err := attempt()
+		if err == nil {
+			r.breaker.Success(modelID)
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			// Cancelled by the caller, not the model's fault
+			r.breaker.Release(modelID)
+			return nil, err
+		}
+
+		if !isRetryableError(err) {
+			// The provider answered, the request itself was at fault
+			var upErr *UpstreamError
+			if errors.As(err, &upErr) {
+				r.breaker.Success(modelID)
+			} else {
+				r.breaker.Release(modelID)
+			}
+			return nil, err
+		}
+		r.breaker.Failure(modelID, err)
+		if started || try >= config.UpstreamMaxRetries {
+			return nil, err
+		}
+
+		delay, ok := retryDelay(err, try)
+		if !ok {
+			return nil, err
+		}
+		slog.Warn("upstream request failed, retrying", "model", modelID, "attempt", try+1, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		if err := r.breaker.Allow(modelID); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isRetryableError reports transient failures: rate limits, overloaded
+// providers and network errors that never produced a complete HTTP response.
+func isRetryableError(err error) bool {
+	var upErr *UpstreamError
+	if errors.As(err, &upErr) {
+		return upErr.Retryable()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// retryDelay returns the wait before the next attempt: the provider's
+// Retry-After if given, otherwise exponential backoff with full jitter.
+// ok is false when the provider asks to wait longer than we are willing to.
+func retryDelay(err error, try int) (delay time.Duration, ok bool) {
+	var upErr *UpstreamError
+	if errors.As(err, &upErr) && upErr.RetryAfter > 0 {
+		if upErr.RetryAfter > config.UpstreamRetryMaxDelay {
+			return 0, false
+		}
+		return upErr.RetryAfter, true
+	}
+
+	backoff := config.UpstreamRetryBaseDelay << try
+	if backoff > config.UpstreamRetryMaxDelay {
+		backoff = config.UpstreamRetryMaxDelay
+	}
+	return time.Duration(rand.Int64N(int64(backoff))) + time.Millisecond, true
+}
+
+func (r *LLMRouter) providerFor(ctx context.Context, modelID string) (LLMProvider, *domain.AIModel, error) {
+	model, err := r.GetModel(ctx, modelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, ok := r.catalog.Provider(model.Provider)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown provider %q for model %s", model.Provider, modelID)
+	}
+	return p, model, nil
+}
+
+// supportedParams drops request parameters the model does not accept, since
+// some providers reject the whole request because of them.
+func supportedParams(model *domain.AIModel, req ChatRequest) ChatRequest {
+	if !model.Capabilities.Temperature {
+		req.Temperature = nil
+	}
+	if !model.Capabilities.Reasoning {
+		req.Reasoning = nil
+	}
+	if !model.Capabilities.TopP {
+		req.TopP = nil
+	}
+	if !model.Capabilities.MaxTokens {
+		req.MaxTokens = nil
+	}
+	if !model.Capabilities.Penalties {
+		req.FrequencyPenalty, req.PresencePenalty = nil, nil
+	}
+	if !model.Capabilities.Seed {
+		req.Seed = nil
+	}
+	if !model.Capabilities.Stop {
+		req.Stop = nil
+	}
+	if f := req.ResponseFormat; f != nil {
+		if (f.Type == "json_schema" && !model.Capabilities.StructuredOutputs) ||
+			(f.Type == "json_object" && !model.Capabilities.JSONMode && !model.Capabilities.StructuredOutputs) {
+			req.ResponseFormat = nil
+		}
+	}
+	return req
+}
+
+// cacheBreakpoints marks the end of the system prompt and of the history
+// before the new message with cache_control, so that models that only cache
+// at breakpoints reuse them on the next turn instead of reading them in full.
+func cacheBreakpoints(model *domain.AIModel, req ChatRequest) ChatRequest {
+	if !model.Capabilities.PromptCaching || len(req.Messages) < 2 {
+		return req
+	}
+	msgs := make([]ChatMessage, len(req.Messages))
+	copy(msgs, req.Messages)
+
+	system := -1
+	for i := 0; i < len(msgs)-1 && msgs[i].Role == "system"; i++ {
+		system = i
+	}
+	history := -1
+	for i := len(msgs) - 2; i > system; i-- {
+		if msgs[i].Role == "user" || msgs[i].Role == "assistant" {
+			history = i
+			break
+		}
+	}
+	for _, i := range []int{system, history} {
+		if i < 0 {
+			continue
+		}
+		if content, ok := withCacheControl(msgs[i].Content); ok {
+			msgs[i].Content = content
+		}
+	}
+	req.Messages = msgs
+	return req
+}
+
+// withCacheControl returns content with a cache breakpoint on its last text
+// part. Parts are copied, since the caller may send the messages again.
+func withCacheControl(content interface{}) (interface{}, bool) {
+	cacheControl := map[string]string{"type": "ephemeral"}
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return nil, false
+		}
+		return []interface{}{
+			map[string]interface{}{"type": "text", "text": c, "cache_control": cacheControl},
+		}, true
+	case []interface{}:
+		for i := len(c) - 1; i >= 0; i-- {
+			part, ok := c[i].(map[string]interface{})
+			if !ok || part["type"] != "text" {
+				continue
+			}
+			marked := make(map[string]interface{}, len(part)+1)
+			for k, v := range part {
+				marked[k] = v
+			}
+			marked["cache_control"] = cacheControl
+			parts := make([]interface{}, len(c))
+			copy(parts, c)
+			parts[i] = marked
+			return parts, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limit", &UpstreamError{Kind: UpstreamRateLimit}, true},
+		{"overloaded", &UpstreamError{Kind: UpstreamOverloaded}, true},
+		{"context length", &UpstreamError{Kind: UpstreamContextLength}, false},
+		{"auth", fmt.Errorf("chat: %w", &UpstreamError{Kind: UpstreamAuth}), false},
+		{"cut off stream", fmt.Errorf("read stream: %w", io.ErrUnexpectedEOF), true},
+		{"transport", fmt.Errorf("chat request: %w", &http.ProtocolError{}), false},
+		{"decode", fmt.Errorf("parse response: %w", errors.New("invalid character")), false},
+		{"unknown model", domain.ErrModelNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWithRetryReleasesProbe checks that a probe of a half-open circuit is
+// always finished, so that the model does not stay blocked.
+func TestWithRetryReleasesProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		cancel bool
+	}{
+		{"cancelled", context.Canceled, true},
+		{"non-retryable upstream answer", &UpstreamError{Kind: UpstreamContextLength}, false},
+		{"non-retryable local error", errors.New("parse response"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker(1, 0)
+			cb.Failure("m", errUpstream)
+			r := &LLMRouter{breaker: cb}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, err := r.withRetry(ctx, "m", func() (*ChatResponse, bool, error) {
+				if tt.cancel {
+					cancel()
+				}
+				return nil, false, tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("withRetry() = %v, want %v", err, tt.err)
+			}
+
+			cb.cooldown = time.Hour
+			if err := cb.Allow("m"); err != nil {
+				t.Errorf("after the probe: Allow() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestCacheBreakpoints(t *testing.T) {
+	caching := &domain.AIModel{Capabilities: domain.ModelCapabilities{PromptCaching: true}}
+	image := map[string]interface{}{"type": "image_url", "image_url": map[string]string{"url": "u"}}
+	messages := []ChatMessage{
+		{Role: "system", Content: "rules"},
+		{Role: "user", Content: "first"},
+		{Role: "assistant", Content: []interface{}{map[string]interface{}{"type": "text", "text": "answer"}, image}},
+		{Role: "tool", Content: "result"},
+		{Role: "user", Content: "new"},
+	}
+
+	req := cacheBreakpoints(caching, ChatRequest{Messages: messages})
+
+	marked := func(content interface{}) bool {
+		parts, ok := content.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, p := range parts {
+			if part, ok := p.(map[string]interface{}); ok && part["cache_control"] != nil {
+				return true
+			}
+		}
+		return false
+	}
+	for i, want := range []bool{true, false, true, false, false} {
+		if got := marked(req.Messages[i].Content); got != want {
+			t.Errorf("message %d (%s) marked = %v, want %v", i, req.Messages[i].Role, got, want)
+		}
+	}
+	// The breakpoint goes on the text part, and the caller's messages are untouched
+	if parts := req.Messages[2].Content.([]interface{}); parts[0].(map[string]interface{})["cache_control"] == nil {
+		t.Error("cache_control is not on the text part")
+	}
+	if marked(messages[2].Content) || messages[0].Content != "rules" {
+		t.Error("cacheBreakpoints changed the original messages")
+	}
+
+	plain := cacheBreakpoints(&domain.AIModel{}, ChatRequest{Messages: messages})
+	if marked(plain.Messages[0].Content) || marked(plain.Messages[2].Content) {
+		t.Error("breakpoints added for a model without prompt caching")
+	}
+}
+
+func TestWithCacheControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		content interface{}
+		ok      bool
+	}{
+		{"text", "hello", true},
+		{"empty text", "", false},
+		{"parts", []interface{}{map[string]interface{}{"type": "text", "text": "hi"}}, true},
+		{"no text part", []interface{}{map[string]interface{}{"type": "image_url"}}, false},
+		{"other", 42, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := withCacheControl(tt.content)
+			if ok != tt.ok {
+				t.Fatalf("withCacheControl() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			parts := got.([]interface{})
+			last := parts[len(parts)-1].(map[string]interface{})
+			if cc, _ := last["cache_control"].(map[string]string); cc["type"] != "ephemeral" {
+				t.Errorf("last part = %v, want an ephemeral cache_control", last)
+			}
+		})
+	}
+}
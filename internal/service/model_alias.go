@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+)
+
+// ModelAliasService keeps the short names users give to models, so that a
+// single message can be sent to another model without switching to it.
+type ModelAliasService struct {
+	queries *sqlc.Queries
+}
+
+func NewModelAliasService(queries *sqlc.Queries) *ModelAliasService {
+	return &ModelAliasService{queries: queries}
+}
+
+// List returns the user's aliases by name.
+func (s *ModelAliasService) List(ctx context.Context, userID int64) ([]domain.ModelAlias, error) {
+	rows, err := s.queries.ListUserModelAliases(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list aliases: %w", err)
+	}
+	aliases := make([]domain.ModelAlias, 0, len(rows))
+	for _, row := range rows {
+		aliases = append(aliases, domain.ModelAlias{Alias: row.Alias, ModelID: row.ModelID})
+	}
+	return aliases, nil
+}
+
+// Resolve returns the model the user's alias stands for.
+func (s *ModelAliasService) Resolve(ctx context.Context, userID int64, alias string) (string, error) {
+	modelID, err := s.queries.GetModelAlias(ctx, sqlc.GetModelAliasParams{UserID: userID, Alias: alias})
+	if err == pgx.ErrNoRows {
+		return "", domain.ErrAliasNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get alias: %w", err)
+	}
+	return modelID, nil
+}
+
+// Set adds an alias or points an existing one to another model. Only new
+// aliases count against config.MaxModelAliases.
+func (s *ModelAliasService) Set(ctx context.Context, userID int64, alias, modelID string) error {
+	if _, err := s.Resolve(ctx, userID, alias); err == domain.ErrAliasNotFound {
+		count, err := s.queries.CountUserModelAliases(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("count aliases: %w", err)
+		}
+		if count >= config.MaxModelAliases {
+			return domain.ErrAliasLimitReached
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if err := s.queries.SetModelAlias(ctx, sqlc.SetModelAliasParams{
+		UserID:  userID,
+		Alias:   alias,
+		ModelID: modelID,
+	}); err != nil {
+		return fmt.Errorf("set alias: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the user's alias.
+func (s *ModelAliasService) Delete(ctx context.Context, userID int64, alias string) error {
+	n, err := s.queries.DeleteModelAlias(ctx, sqlc.DeleteModelAliasParams{UserID: userID, Alias: alias})
+	if err != nil {
+		return fmt.Errorf("delete alias: %w", err)
+	}
+	if n == 0 {
+		return domain.ErrAliasNotFound
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+)
+
+// ModelCatalog keeps the merged model list of all providers in memory, backed
+// by the ai_models table. It is refreshed in the background, so requests never
+// wait for a provider's /models endpoint, and a provider that is down keeps its
+// last known models. Price changes are recorded in model_price_history.
+type ModelCatalog struct {
+	queries   *sqlc.Queries
+	providers []LLMProvider
+	byName    map[string]LLMProvider
+
+	mu        sync.RWMutex
+	models    []domain.AIModel
+	refreshMu sync.Mutex
+}
+
+func NewModelCatalog(queries *sqlc.Queries, providers ...LLMProvider) *ModelCatalog {
+	byName := make(map[string]LLMProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &ModelCatalog{queries: queries, providers: providers, byName: byName}
+}
+
+// Provider returns the provider with the given name.
+func (c *ModelCatalog) Provider(name string) (LLMProvider, bool) {
+	p, ok := c.byName[name]
+	return p, ok
+}
+
+// Load reads the stored catalogue, so the bot can serve models right after a
+// restart. If nothing is stored yet it fetches the providers instead.
+func (c *ModelCatalog) Load(ctx context.Context) error {
+	rows, err := c.queries.ListAvailableAIModels(ctx)
+	if err != nil {
+		return fmt.Errorf("list stored models: %w", err)
+	}
+
+	models := make([]domain.AIModel, 0, len(rows))
+	for _, row := range rows {
+		var m domain.AIModel
+		if err := json.Unmarshal(row.Data, &m); err != nil {
+			slog.Error("decode stored model", "model", row.ID, "error", err)
+			continue
+		}
+		models = append(models, m)
+	}
+	if len(models) == 0 {
+		return c.Refresh(ctx)
+	}
+
+	c.mu.Lock()
+	c.models = models
+	c.mu.Unlock()
+	return nil
+}
+
+// Models returns the current catalogue, fetching it if it was never loaded.
+func (c *ModelCatalog) Models(ctx context.Context) ([]domain.AIModel, error) {
+	c.mu.RLock()
+	models := c.models
+	c.mu.RUnlock()
+	if models != nil {
+		return models, nil
+	}
+
+	err := c.Refresh(ctx)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.models == nil {
+		return nil, err
+	}
+	return c.models, nil
+}
+
+// Refresh fetches every provider's models and stores them. A provider that
+// fails, or returns nothing, keeps the models it had before; models missing
+// from a successful answer are marked as removed. The error of a failed
+// provider is returned even though the others were refreshed.
+func (c *ModelCatalog) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.RLock()
+	previous := make(map[string]domain.AIModel, len(c.models))
+	for _, m := range c.models {
+		previous[m.ID] = m
+	}
+	c.mu.RUnlock()
+
+	var all []domain.AIModel
+	var lastErr error
+	for _, p := range c.providers {
+		models, err := p.ListModels(ctx)
+		if err == nil && len(models) == 0 {
+			err = fmt.Errorf("empty model list")
+		}
+		if err != nil {
+			slog.Error("list provider models", "provider", p.Name(), "error", err)
+			lastErr = err
+			for _, m := range previous {
+				if m.Provider == p.Name() {
+					all = append(all, m)
+				}
+			}
+			continue
+		}
+
+		if err := c.store(ctx, p.Name(), models, previous); err != nil {
+			slog.Error("store provider models", "provider", p.Name(), "error", err)
+		}
+		all = append(all, models...)
+	}
+	if all != nil {
+		c.mu.Lock()
+		c.models = all
+		c.mu.Unlock()
+	}
+	return lastErr
+}
+
+// store upserts a provider's models, records new prices and marks the models
+// the provider no longer lists.
+func (c *ModelCatalog) store(ctx context.Context, provider string, models []domain.AIModel, previous map[string]domain.AIModel) error {
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+
+		data, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("encode model %s: %w", m.ID, err)
+		}
+		// Only one of the two image prices is set, see OpenRouterService
+		imagePrice := m.ImagePrice + m.InputImagePrice
+		if err := c.queries.UpsertAIModel(ctx, sqlc.UpsertAIModelParams{
+			ID:              m.ID,
+			Provider:        provider,
+			Data:            data,
+			PromptPrice:     m.PromptPrice,
+			CompletionPrice: m.CompletionPrice,
+			ImagePrice:      imagePrice,
+			RequestPrice:    m.RequestPrice,
+		}); err != nil {
+			return fmt.Errorf("upsert model %s: %w", m.ID, err)
+		}
+
+		old, known := previous[m.ID]
+		if known && old.PromptPrice == m.PromptPrice && old.CompletionPrice == m.CompletionPrice &&
+			old.ImagePrice+old.InputImagePrice == imagePrice && old.RequestPrice == m.RequestPrice {
+			continue
+		}
+		if err := c.queries.AddModelPriceHistory(ctx, sqlc.AddModelPriceHistoryParams{
+			ModelID:         m.ID,
+			PromptPrice:     m.PromptPrice,
+			CompletionPrice: m.CompletionPrice,
+			ImagePrice:      imagePrice,
+			RequestPrice:    m.RequestPrice,
+		}); err != nil {
+			return fmt.Errorf("record price of %s: %w", m.ID, err)
+		}
+	}
+
+	removed, err := c.queries.MarkAIModelsRemoved(ctx, sqlc.MarkAIModelsRemovedParams{
+		Provider: provider,
+		Ids:      ids,
+	})
+	if err != nil {
+		return fmt.Errorf("mark removed models: %w", err)
+	}
+	if len(removed) > 0 {
+		slog.Info("models removed by provider", "provider", provider, "models", removed)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+)
+
+// ModelMigration describes users, groups, sessions and aliases moved from a
+// model that left the catalogue to its replacement.
+type ModelMigration struct {
+	From     string
+	To       string
+	Users    []int64 // telegram IDs
+	Groups   []sqlc.ReplaceGroupModelRow
+	Sessions int64
+	Aliases  int64
+}
+
+// ModelReplacementService moves everyone off models that disappeared from the
+// catalogue. Admins choose the replacement per model; models without one are
+// replaced by config.DefaultModel.
+type ModelReplacementService struct {
+	db      *pgxpool.Pool
+	queries *sqlc.Queries
+	llm     *LLMRouter
+}
+
+func NewModelReplacementService(db *pgxpool.Pool, queries *sqlc.Queries, llm *LLMRouter) *ModelReplacementService {
+	return &ModelReplacementService{db: db, queries: queries, llm: llm}
+}
+
+func (s *ModelReplacementService) Set(ctx context.Context, modelID, replacement string) error {
+	return s.queries.SetModelReplacement(ctx, sqlc.SetModelReplacementParams{
+		ModelID:     modelID,
+		Replacement: replacement,
+	})
+}
+
+func (s *ModelReplacementService) Clear(ctx context.Context, modelID string) error {
+	return s.queries.DeleteModelReplacement(ctx, modelID)
+}
+
+func (s *ModelReplacementService) List(ctx context.Context) ([]sqlc.ModelReplacement, error) {
+	return s.queries.ListModelReplacements(ctx)
+}
+
+// MigrateRemoved replaces every model referenced by users, groups, sessions or
+// aliases that is not in the catalogue. Only run it after a complete catalogue
+// refresh, or models of an unreachable provider would be replaced too.
+func (s *ModelReplacementService) MigrateRemoved(ctx context.Context) ([]ModelMigration, error) {
+	models, err := s.llm.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	available := make(map[string]bool, len(models))
+	for _, m := range models {
+		available[m.ID] = true
+	}
+
+	referenced, err := s.queries.ListReferencedModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list referenced models: %w", err)
+	}
+
+	var migrations []ModelMigration
+	for _, modelID := range referenced {
+		if available[modelID] {
+			continue
+		}
+
+		replacement, err := s.queries.GetModelReplacement(ctx, modelID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return migrations, fmt.Errorf("get replacement: %w", err)
+		}
+		if !available[replacement] {
+			replacement = config.DefaultModel
+		}
+		if !available[replacement] {
+			slog.Warn("no replacement for removed model", "model", modelID)
+			continue
+		}
+
+		m, err := s.migrate(ctx, modelID, replacement)
+		if err != nil {
+			return migrations, fmt.Errorf("replace %s: %w", modelID, err)
+		}
+		slog.Info("replaced removed model", "model", modelID, "replacement", replacement,
+			"users", len(m.Users), "groups", len(m.Groups), "sessions", m.Sessions, "aliases", m.Aliases)
+		migrations = append(migrations, m)
+	}
+	return migrations, nil
+}
+
+func (s *ModelReplacementService) migrate(ctx context.Context, from, to string) (ModelMigration, error) {
+	m := ModelMigration{From: from, To: to}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return m, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+
+	m.Users, err = qtx.ReplaceUserModel(ctx, sqlc.ReplaceUserModelParams{OldModel: from, NewModel: to})
+	if err != nil {
+		return m, fmt.Errorf("replace user model: %w", err)
+	}
+	m.Groups, err = qtx.ReplaceGroupModel(ctx, sqlc.ReplaceGroupModelParams{NewModel: to, OldModel: from})
+	if err != nil {
+		return m, fmt.Errorf("replace group model: %w", err)
+	}
+	m.Sessions, err = qtx.ReplaceSessionModel(ctx, sqlc.ReplaceSessionModelParams{NewModel: to, OldModel: from})
+	if err != nil {
+		return m, fmt.Errorf("replace session model: %w", err)
+	}
+	m.Aliases, err = qtx.ReplaceAliasModel(ctx, sqlc.ReplaceAliasModelParams{NewModel: to, OldModel: from})
+	if err != nil {
+		return m, fmt.Errorf("replace alias model: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return m, fmt.Errorf("commit: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+// OpenAICompatService serves models from any OpenAI-compatible API, such as a
+// local vLLM or Ollama server. Model IDs are prefixed with the provider name
+// so they never collide with OpenRouter IDs.
+type OpenAICompatService struct {
+	chatClient
+	providerName    string
+	promptPrice     float64
+	completionPrice float64
+	contextLength   int
+	tools           bool
+	reasoning       bool
+}
+
+func NewOpenAICompatService(cfg *config.Config) *OpenAICompatService {
+	return &OpenAICompatService{
+		chatClient: chatClient{
+			name:       cfg.OpenAICompatName,
+			apiKey:     cfg.OpenAICompatAPIKey,
+			baseURL:    strings.TrimRight(cfg.OpenAICompatBaseURL, "/"),
+			httpClient: &http.Client{Timeout: config.RequestTimeout},
+		},
+		providerName:    cfg.OpenAICompatName,
+		promptPrice:     cfg.OpenAICompatPromptPrice,
+		completionPrice: cfg.OpenAICompatCompletionPrice,
+		contextLength:   cfg.OpenAICompatContextLength,
+		tools:           cfg.OpenAICompatTools,
+		reasoning:       cfg.OpenAICompatReasoning,
+	}
+}
+
+func (s *OpenAICompatService) Name() string {
+	return s.providerName
+}
+
+func (s *OpenAICompatService) ListModels(ctx context.Context) ([]domain.AIModel, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	var result struct {
+		Data []struct {
+			ID          string `json:"id"`
+			OwnedBy     string `json:"owned_by"`
+			MaxModelLen int    `json:"max_model_len"` // reported by vLLM
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse models: %w", err)
+	}
+
+	models := make([]domain.AIModel, 0, len(result.Data))
+	for _, m := range result.Data {
+		ctxLen := s.contextLength
+		if m.MaxModelLen > 0 {
+			ctxLen = m.MaxModelLen
+		}
+		models = append(models, domain.AIModel{
+			ID:              s.providerName + "/" + m.ID,
+			Provider:        s.providerName,
+			Name:            fmt.Sprintf("%s (%s)", m.ID, s.providerName),
+			Description:     m.OwnedBy,
+			PromptPrice:     s.promptPrice,
+			CompletionPrice: s.completionPrice,
+			ContextLength:   ctxLen,
+			Capabilities: domain.ModelCapabilities{
+				Tools:     s.tools,
+				Reasoning: s.reasoning,
+				// The OpenAI API parameters every compatible server accepts
+				Temperature: true,
+				TopP:        true,
+				MaxTokens:   true,
+				Penalties:   true,
+				Seed:        true,
+				Stop:        true,
+				JSONMode:    true,
+			},
+		})
+	}
+
+	return models, nil
+}
+
+func (s *OpenAICompatService) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return s.chat(ctx, s.upstreamRequest(req))
+}
+
+func (s *OpenAICompatService) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string)) (*ChatResponse, error) {
+	return s.chatStream(ctx, s.upstreamRequest(req), onDelta)
+}
+
+// upstreamRequest adapts a request to the plain OpenAI API: the model ID loses
+// its provider prefix and the reasoning object becomes reasoning_effort.
+func (s *OpenAICompatService) upstreamRequest(req ChatRequest) ChatRequest {
+	req.Model = s.upstreamModelID(req.Model)
+	if req.Reasoning != nil {
+		req.ReasoningEffort = req.Reasoning.Effort
+		req.Reasoning = nil
+	}
+	return req
+}
+
+// upstreamModelID strips the provider prefix added in ListModels.
+func (s *OpenAICompatService) upstreamModelID(modelID string) string {
+	return strings.TrimPrefix(modelID, s.providerName+"/")
+}
@@ -1,63 +1,41 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/set-night/mindapp/internal/config"
 	"github.com/set-night/mindapp/internal/domain"
 )
 
+// ProviderOpenRouter is the provider name of models served by OpenRouter.
+const ProviderOpenRouter = "openrouter"
+
 type OpenRouterService struct {
-	apiKey     string
-	baseURL    string
-	httpClient *http.Client
-	cache      *ModelsCache
+	chatClient
 }
 
-func NewOpenRouterService(apiKey string) *OpenRouterService {
+func NewOpenRouterService(apiKey, baseURL string) *OpenRouterService {
 	return &OpenRouterService{
-		apiKey:     apiKey,
-		baseURL:    "https://openrouter.ai/api/v1",
-		httpClient: &http.Client{Timeout: config.RequestTimeout},
-		cache:      NewModelsCache(config.ModelCacheDuration),
+		chatClient: chatClient{
+			name:       "OpenRouter",
+			apiKey:     apiKey,
+			baseURL:    baseURL,
+			httpClient: &http.Client{Timeout: config.RequestTimeout},
+		},
 	}
 }
 
-type ChatMessage struct {
-	Role    string      `json:"role"`
-	Content interface{} `json:"content"`
-}
-
-type ChatRequest struct {
-	Model       string        `json:"model"`
-	Messages    []ChatMessage `json:"messages"`
-	Temperature *float64      `json:"temperature,omitempty"`
-}
-
-type ChatResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int     `json:"prompt_tokens"`
-		CompletionTokens int     `json:"completion_tokens"`
-		TotalCost        float64 `json:"total_cost"`
-	} `json:"usage"`
+func (s *OpenRouterService) Name() string {
+	return ProviderOpenRouter
 }
 
 func (s *OpenRouterService) ListModels(ctx context.Context) ([]domain.AIModel, error) {
-	if cached := s.cache.Get(); cached != nil {
-		return cached, nil
-	}
-
 	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/models", nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
@@ -83,14 +61,22 @@ func (s *OpenRouterService) ListModels(ctx context.Context) ([]domain.AIModel, e
 			Pricing     struct {
 				Prompt     string `json:"prompt"`
 				Completion string `json:"completion"`
+				Image      string `json:"image"`
+				Request    string `json:"request"`
+				CacheRead  string `json:"input_cache_read"`
+				CacheWrite string `json:"input_cache_write"`
 			} `json:"pricing"`
 			ContextLength int `json:"context_length"`
 			TopProvider   struct {
-				ContextLength int `json:"context_length"`
+				ContextLength       int `json:"context_length"`
+				MaxCompletionTokens int `json:"max_completion_tokens"`
 			} `json:"top_provider"`
 			Architecture struct {
-				Modality string `json:"modality"`
+				Modality         string   `json:"modality"`
+				InputModalities  []string `json:"input_modalities"`
+				OutputModalities []string `json:"output_modalities"`
 			} `json:"architecture"`
+			SupportedParameters []string `json:"supported_parameters"`
 		} `json:"data"`
 	}
 
@@ -100,13 +86,20 @@ func (s *OpenRouterService) ListModels(ctx context.Context) ([]domain.AIModel, e
 
 	models := make([]domain.AIModel, 0, len(result.Data))
 	for _, m := range result.Data {
-		var promptPrice, completionPrice float64
+		var promptPrice, completionPrice, imagePrice, requestPrice float64
 		fmt.Sscanf(m.Pricing.Prompt, "%f", &promptPrice)
 		fmt.Sscanf(m.Pricing.Completion, "%f", &completionPrice)
+		fmt.Sscanf(m.Pricing.Image, "%f", &imagePrice)
+		fmt.Sscanf(m.Pricing.Request, "%f", &requestPrice)
+		var cacheReadPrice, cacheWritePrice float64
+		fmt.Sscanf(m.Pricing.CacheRead, "%f", &cacheReadPrice)
+		fmt.Sscanf(m.Pricing.CacheWrite, "%f", &cacheWritePrice)
 
 		// Prices from OpenRouter are per token, convert to per 1M tokens
 		promptPrice *= 1_000_000
 		completionPrice *= 1_000_000
+		cacheReadPrice *= 1_000_000
+		cacheWritePrice *= 1_000_000
 
 		ctxLen := m.ContextLength
 		if m.TopProvider.ContextLength > 0 {
@@ -115,109 +108,123 @@ func (s *OpenRouterService) ListModels(ctx context.Context) ([]domain.AIModel, e
 
 		model := domain.AIModel{
 			ID:              m.ID,
+			Provider:        ProviderOpenRouter,
 			Name:            m.Name,
 			Description:     m.Description,
 			PromptPrice:     promptPrice,
 			CompletionPrice: completionPrice,
+			RequestPrice:    requestPrice,
+			CacheReadPrice:  cacheReadPrice,
+			CacheWritePrice: cacheWritePrice,
 			ContextLength:   ctxLen,
-			Capabilities:    detectCapabilities(m.ID, m.Architecture.Modality),
+			MaxOutputTokens: m.TopProvider.MaxCompletionTokens,
+			Capabilities:    parseCapabilities(m.Architecture.Modality, m.Architecture.InputModalities, m.Architecture.OutputModalities, m.SupportedParameters),
+		}
+		// Models that charge for cache writes (Anthropic, Gemini) only cache
+		// at explicit breakpoints; the others cache repeated prefixes by
+		// themselves
+		model.Capabilities.PromptCaching = cacheWritePrice > 0
+		// OpenRouter has a single image price: for generated images on image
+		// models and for input images on the rest
+		if model.Capabilities.ImageGeneration {
+			model.ImagePrice = imagePrice
+		} else if model.Capabilities.Vision {
+			model.InputImagePrice = imagePrice
 		}
 		models = append(models, model)
 	}
 
-	s.cache.Set(models)
 	return models, nil
 }
 
-func (s *OpenRouterService) Chat(ctx context.Context, messages []ChatMessage, model string, temperature *float64) (*ChatResponse, error) {
-	// Skip temperature for Gemini models
-	if strings.Contains(strings.ToLower(model), "gemini") {
-		temperature = nil
-	}
-
-	chatReq := ChatRequest{
-		Model:       model,
-		Messages:    messages,
-		Temperature: temperature,
-	}
+func (s *OpenRouterService) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return s.chat(ctx, req)
+}
 
-	payload, err := json.Marshal(chatReq)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request: %w", err)
-	}
+func (s *OpenRouterService) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string)) (*ChatResponse, error) {
+	return s.chatStream(ctx, req, onDelta)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/chat/completions", bytes.NewReader(payload))
+// GenerationCost returns what OpenRouter charged for a generation, in USD.
+// The stats become available a little after the generation finishes; until
+// then the request fails with a not-found *UpstreamError.
+func (s *OpenRouterService) GenerationCost(ctx context.Context, id string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/generation?id="+url.QueryEscape(id), nil)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return 0, fmt.Errorf("create request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+s.apiKey)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("chat request: %w", err)
+		return 0, fmt.Errorf("fetch generation: %w", err)
 	}
 	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, fmt.Errorf("rate limited by OpenRouter (429)")
-	}
-	if resp.StatusCode == http.StatusServiceUnavailable {
-		return nil, fmt.Errorf("OpenRouter service unavailable (503)")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, newUpstreamError(s.name, resp)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+	var result struct {
+		Data struct {
+			TotalCost float64 `json:"total_cost"`
+		} `json:"data"`
 	}
-
-	var chatResp ChatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("parse generation: %w", err)
 	}
-
-	return &chatResp, nil
+	return result.Data.TotalCost, nil
 }
 
-func (s *OpenRouterService) GetModel(ctx context.Context, modelID string) (*domain.AIModel, error) {
-	models, err := s.ListModels(ctx)
-	if err != nil {
-		return nil, err
-	}
-	for _, m := range models {
-		if m.ID == modelID {
-			return &m, nil
+// parseCapabilities derives model capabilities from OpenRouter metadata. Older
+// entries only have the "text+image->text" modality string. Models that list
+// no supported parameters are assumed to accept the basic sampling ones.
+func parseCapabilities(modality string, input, output, params []string) domain.ModelCapabilities {
+	if len(input) == 0 && len(output) == 0 {
+		in, out, _ := strings.Cut(modality, "->")
+		input = strings.Split(in, "+")
+		output = strings.Split(out, "+")
+	}
+
+	basic := len(params) == 0
+	caps := domain.ModelCapabilities{Temperature: basic, TopP: basic, MaxTokens: basic, Stop: basic}
+	for _, in := range input {
+		switch in {
+		case "image":
+			caps.Vision = true
+		case "audio":
+			caps.Audio = true
+		case "file":
+			caps.Files = true
 		}
 	}
-	return nil, domain.ErrModelNotFound
-}
-
-func detectCapabilities(modelID, modality string) domain.ModelCapabilities {
-	id := strings.ToLower(modelID)
-	caps := domain.ModelCapabilities{}
-
-	// Vision detection
-	if strings.Contains(id, "vision") || strings.Contains(id, "gpt-4o") ||
-		strings.Contains(id, "claude-3") || strings.Contains(id, "gemini") ||
-		strings.Contains(id, "llava") || strings.Contains(modality, "image") {
-		caps.Vision = true
-	}
-
-	// Audio detection
-	if strings.Contains(id, "audio") || strings.Contains(modality, "audio") {
-		caps.Audio = true
-	}
-
-	// Image generation
-	if strings.Contains(id, "dall-e") || strings.Contains(id, "stable-diffusion") ||
-		strings.Contains(id, "flux") || strings.Contains(id, "imagen") {
-		caps.ImageGeneration = true
+	for _, out := range output {
+		if out == "image" {
+			caps.ImageGeneration = true
+		}
 	}
-
-	// File support (vision models generally support files)
-	if caps.Vision {
-		caps.Files = true
+	for _, p := range params {
+		switch p {
+		case "tools":
+			caps.Tools = true
+		case "temperature":
+			caps.Temperature = true
+		case "top_p":
+			caps.TopP = true
+		case "max_tokens":
+			caps.MaxTokens = true
+		case "frequency_penalty", "presence_penalty":
+			caps.Penalties = true
+		case "seed":
+			caps.Seed = true
+		case "stop":
+			caps.Stop = true
+		case "reasoning", "include_reasoning":
+			caps.Reasoning = true
+		case "response_format":
+			caps.JSONMode = true
+		case "structured_outputs":
+			caps.StructuredOutputs = true
+		}
 	}
-
 	return caps
 }
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		modality string
+		input    []string
+		output   []string
+		params   []string
+		want     domain.ModelCapabilities
+	}{
+		{
+			name:     "modality string only",
+			modality: "text+image->text",
+			want:     domain.ModelCapabilities{Vision: true, Temperature: true, TopP: true, MaxTokens: true, Stop: true},
+		},
+		{
+			name:     "input and output lists win over modality",
+			modality: "text->text",
+			input:    []string{"text", "image", "audio", "file"},
+			output:   []string{"text", "image"},
+			params:   []string{"temperature"},
+			want:     domain.ModelCapabilities{Vision: true, Audio: true, Files: true, ImageGeneration: true, Temperature: true},
+		},
+		{
+			name:   "supported parameters",
+			input:  []string{"text"},
+			output: []string{"text"},
+			params: []string{
+				"tools", "temperature", "top_p", "max_tokens", "frequency_penalty", "seed",
+				"stop", "include_reasoning", "response_format", "structured_outputs", "unknown",
+			},
+			want: domain.ModelCapabilities{
+				Tools: true, Temperature: true, TopP: true, MaxTokens: true, Penalties: true, Seed: true,
+				Stop: true, Reasoning: true, JSONMode: true, StructuredOutputs: true,
+			},
+		},
+		{
+			name:   "parameters listed without sampling ones",
+			input:  []string{"text"},
+			output: []string{"text"},
+			params: []string{"reasoning"},
+			want:   domain.ModelCapabilities{Reasoning: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCapabilities(tt.modality, tt.input, tt.output, tt.params); got != tt.want {
+				t.Errorf("parseCapabilities() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
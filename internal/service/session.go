@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -36,7 +39,32 @@ func (s *SessionService) FindOrCreate(ctx context.Context, user *domain.User) (*
 }
 
 func (s *SessionService) CreateNew(ctx context.Context, user *domain.User) (*domain.ChatSession, error) {
-	// Enforce session limit
+	if err := s.limitSessions(ctx, user, 1); err != nil {
+		return nil, err
+	}
+
+	row, err := s.queries.CreateSession(ctx, sqlc.CreateSessionParams{
+		UserID:      user.ID,
+		Model:       user.SelectedModel,
+		Temperature: decimal.NewFromFloat(user.Temperature),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+
+	if err := s.queries.SetUserActiveSession(ctx, sqlc.SetUserActiveSessionParams{
+		ID:              user.ID,
+		ActiveSessionID: &row.ID,
+	}); err != nil {
+		return nil, fmt.Errorf("set active session: %w", err)
+	}
+
+	return rowToSession(row), nil
+}
+
+// limitSessions deletes the user's oldest sessions so that extra more fit
+// into the session limit.
+func (s *SessionService) limitSessions(ctx context.Context, user *domain.User, extra int64) error {
 	maxSessions := config.MaxSessionsRegular
 	if user.IsPremium() {
 		maxSessions = config.MaxSessionsPremium
@@ -44,35 +72,108 @@ func (s *SessionService) CreateNew(ctx context.Context, user *domain.User) (*dom
 
 	count, err := s.queries.CountSessionsByUserID(ctx, user.ID)
 	if err != nil {
-		return nil, fmt.Errorf("count sessions: %w", err)
+		return fmt.Errorf("count sessions: %w", err)
 	}
 
-	if count >= int64(maxSessions) {
-		toDelete := count - int64(maxSessions) + 1
+	if toDelete := count + extra - int64(maxSessions); toDelete > 0 {
 		if err := s.queries.DeleteOldestUserSessions(ctx, sqlc.DeleteOldestUserSessionsParams{
 			UserID: user.ID,
 			Limit:  int32(toDelete),
 		}); err != nil {
-			return nil, fmt.Errorf("delete oldest sessions: %w", err)
+			return fmt.Errorf("delete oldest sessions: %w", err)
 		}
 	}
+	return nil
+}
 
-	row, err := s.queries.CreateSession(ctx, sqlc.CreateSessionParams{
+// ForkAt branches a conversation at one of the user's answers, found by a
+// chat message it was sent in: the new session gets the history up to and
+// including the answer and becomes active. It returns nil if there is no such
+// answer, or if it is the last one of the active session, where a reply just
+// continues the conversation.
+func (s *SessionService) ForkAt(ctx context.Context, user *domain.User, tgMessageID int64) (*domain.ChatSession, error) {
+	answer, err := s.queries.GetUserAnswerByTelegramID(ctx, sqlc.GetUserAnswerByTelegramIDParams{
 		UserID:      user.ID,
-		Model:       user.SelectedModel,
-		Temperature: decimal.NewFromFloat(user.Temperature),
+		TgMessageID: tgMessageID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get answer: %w", err)
+	}
+	parent, err := s.queries.GetSessionByID(ctx, answer.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	history, err := s.queries.GetSessionMessages(ctx, answer.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("get messages: %w", err)
+	}
+
+	// An archived answer only lives on in the summary
+	idx := slices.IndexFunc(history, func(m sqlc.SessionMessage) bool { return m.ID == answer.ID })
+	if idx < 0 {
+		return nil, nil
+	}
+	active := user.ActiveSessionID != nil && *user.ActiveSessionID == parent.ID
+	if active && idx == len(history)-1 {
+		return nil, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+
+	row, err := qtx.CreateForkSession(ctx, sqlc.CreateForkSessionParams{
+		UserID:          user.ID,
+		Model:           parent.Model,
+		Temperature:     parent.Temperature,
+		Params:          parent.Params,
+		ParentSessionID: &parent.ID,
+		ForkMessageID:   &answer.ID,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create session: %w", err)
 	}
 
-	if err := s.queries.SetUserActiveSession(ctx, sqlc.SetUserActiveSessionParams{
+	// The chat messages stay with the original, so replies keep forking from it
+	for _, m := range history[:idx+1] {
+		id, err := qtx.CopySessionMessage(ctx, sqlc.CopySessionMessageParams{
+			SessionID: row.ID,
+			ID:        m.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("copy message: %w", err)
+		}
+		if err := qtx.CopyMessageFiles(ctx, sqlc.CopyMessageFilesParams{
+			NewMessageID: id,
+			MessageID:    m.ID,
+		}); err != nil {
+			return nil, fmt.Errorf("copy message files: %w", err)
+		}
+	}
+
+	if err := qtx.SetUserActiveSession(ctx, sqlc.SetUserActiveSessionParams{
 		ID:              user.ID,
 		ActiveSessionID: &row.ID,
 	}); err != nil {
 		return nil, fmt.Errorf("set active session: %w", err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	user.ActiveSessionID = &row.ID
+
+	// The fork is the newest session, so only older ones make room for it
+	if err := s.limitSessions(ctx, user, 0); err != nil {
+		return nil, err
+	}
 	return rowToSession(row), nil
 }
 
@@ -161,15 +262,34 @@ func (s *SessionService) AddMessage(ctx context.Context, sessionID int64, role,
 	if err != nil {
 		return nil, fmt.Errorf("add message: %w", err)
 	}
-	return &domain.SessionMessage{
-		ID:        row.ID,
-		SessionID: row.SessionID,
-		Role:      row.Role,
-		Text:      row.Text,
-		Images:    row.Images,
-		IsSystem:  row.IsSystem,
-		CreatedAt: pgTimestamptzToTime(row.CreatedAt),
-	}, nil
+	msg := rowToSessionMessage(row)
+	return &msg, nil
+}
+
+// AddToolMessages stores the tool calls made while answering and their results,
+// in the order they were exchanged with the model.
+func (s *SessionService) AddToolMessages(ctx context.Context, sessionID int64, msgs []ChatMessage) error {
+	for _, m := range msgs {
+		var toolCalls []byte
+		if len(m.ToolCalls) > 0 {
+			var err error
+			toolCalls, err = json.Marshal(m.ToolCalls)
+			if err != nil {
+				return fmt.Errorf("marshal tool calls: %w", err)
+			}
+		}
+		text, _ := m.Content.(string)
+		if _, err := s.queries.AddToolSessionMessage(ctx, sqlc.AddToolSessionMessageParams{
+			SessionID:  sessionID,
+			Role:       m.Role,
+			Text:       text,
+			ToolCalls:  toolCalls,
+			ToolCallID: m.ToolCallID,
+		}); err != nil {
+			return fmt.Errorf("add tool message: %w", err)
+		}
+	}
+	return nil
 }
 
 func (s *SessionService) GetMessages(ctx context.Context, sessionID int64) ([]domain.SessionMessage, error) {
@@ -179,15 +299,7 @@ func (s *SessionService) GetMessages(ctx context.Context, sessionID int64) ([]do
 	}
 	msgs := make([]domain.SessionMessage, len(rows))
 	for i, r := range rows {
-		msgs[i] = domain.SessionMessage{
-			ID:        r.ID,
-			SessionID: r.SessionID,
-			Role:      r.Role,
-			Text:      r.Text,
-			Images:    r.Images,
-			IsSystem:  r.IsSystem,
-			CreatedAt: pgTimestamptzToTime(r.CreatedAt),
-		}
+		msgs[i] = rowToSessionMessage(r)
 	}
 	return msgs, nil
 }
@@ -212,6 +324,50 @@ func (s *SessionService) GetFirstMessage(ctx context.Context, sessionID int64) (
 	}, nil
 }
 
+// GetUserMessage returns a message from one of the user's sessions.
+func (s *SessionService) GetUserMessage(ctx context.Context, userID, messageID int64) (*domain.SessionMessage, error) {
+	row, err := s.queries.GetUserSessionMessage(ctx, sqlc.GetUserSessionMessageParams{
+		ID:     messageID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("get message: %w", err)
+	}
+	msg := rowToSessionMessage(row)
+	return &msg, nil
+}
+
+// DeleteMessages removes messages from a session, e.g. an answer that is
+// being regenerated.
+func (s *SessionService) DeleteMessages(ctx context.Context, sessionID int64, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.queries.DeleteSessionMessages(ctx, sqlc.DeleteSessionMessagesParams{
+		SessionID: sessionID,
+		Ids:       ids,
+	})
+}
+
+// SetTelegramMessageIDs records the chat messages that show a stored message.
+func (s *SessionService) SetTelegramMessageIDs(ctx context.Context, messageID int64, ids []int64) error {
+	return s.queries.SetSessionMessageTelegramIDs(ctx, sqlc.SetSessionMessageTelegramIDsParams{
+		ID:           messageID,
+		TgMessageIds: ids,
+	})
+}
+
+// UpdateMessageText replaces the text of a stored message.
+func (s *SessionService) UpdateMessageText(ctx context.Context, messageID int64, text string) error {
+	return s.queries.UpdateSessionMessageText(ctx, sqlc.UpdateSessionMessageTextParams{
+		ID:   messageID,
+		Text: text,
+	})
+}
+
 func (s *SessionService) AddMessageFile(ctx context.Context, messageID int64, fileType, url, name string) error {
 	return s.queries.AddMessageFile(ctx, sqlc.AddMessageFileParams{
 		MessageID: messageID,
@@ -221,13 +377,199 @@ func (s *SessionService) AddMessageFile(ctx context.Context, messageID int64, fi
 	})
 }
 
+// GetMessageFiles returns the files attached to a message.
+func (s *SessionService) GetMessageFiles(ctx context.Context, messageID int64) ([]domain.MessageFile, error) {
+	rows, err := s.queries.GetMessageFiles(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("get message files: %w", err)
+	}
+	files := make([]domain.MessageFile, len(rows))
+	for i, r := range rows {
+		files[i] = domain.MessageFile{
+			ID:        r.ID,
+			MessageID: r.MessageID,
+			FileType:  r.FileType,
+			URL:       r.Url,
+			Name:      r.Name,
+		}
+	}
+	return files, nil
+}
+
+// UpdateTemperature sets the sampling temperature of a session.
+func (s *SessionService) UpdateTemperature(ctx context.Context, sessionID int64, temperature float64) error {
+	return s.queries.UpdateSessionTemperature(ctx, sqlc.UpdateSessionTemperatureParams{
+		ID:          sessionID,
+		Temperature: decimal.NewFromFloat(temperature),
+	})
+}
+
+// UpdateParams replaces the generation parameters of a session.
+func (s *SessionService) UpdateParams(ctx context.Context, sessionID int64, params domain.SessionParams) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("encode params: %w", err)
+	}
+	return s.queries.UpdateSessionParams(ctx, sqlc.UpdateSessionParamsParams{
+		ID:     sessionID,
+		Params: data,
+	})
+}
+
+// ApplySessionParams copies the session's generation parameters into a
+// request. Parameters the model does not support are dropped by LLMRouter.
+func ApplySessionParams(req ChatRequest, p domain.SessionParams) ChatRequest {
+	req.TopP = p.TopP
+	req.MaxTokens = p.MaxTokens
+	req.FrequencyPenalty = p.FrequencyPenalty
+	req.PresencePenalty = p.PresencePenalty
+	req.Seed = p.Seed
+	req.Stop = p.Stop
+	switch p.ResponseFormat {
+	case "json_object":
+		req.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	case "json_schema":
+		req.ResponseFormat = &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &JSONSchemaFormat{Name: "response", Strict: StrictSchema(p.JSONSchema), Schema: p.JSONSchema},
+		}
+	}
+	return req
+}
+
+// StrictSchema reports whether a JSON schema meets the rules of strict
+// structured outputs: every object lists all its properties as required and
+// sets additionalProperties to false. Providers reject other schemas in
+// strict mode, so they are sent without it.
+func StrictSchema(schema json.RawMessage) bool {
+	var v interface{}
+	if err := json.Unmarshal(schema, &v); err != nil {
+		return false
+	}
+	return strictSchemaNode(v)
+}
+
+func strictSchemaNode(node interface{}) bool {
+	if list, ok := node.([]interface{}); ok {
+		for _, item := range list {
+			if !strictSchemaNode(item) {
+				return false
+			}
+		}
+		return true
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return true
+	}
+
+	props, hasProps := schema["properties"].(map[string]interface{})
+	if schema["type"] == "object" || hasProps {
+		if schema["additionalProperties"] != false {
+			return false
+		}
+		required := make(map[string]bool)
+		if list, ok := schema["required"].([]interface{}); ok {
+			for _, name := range list {
+				if s, ok := name.(string); ok {
+					required[s] = true
+				}
+			}
+		}
+		for name, prop := range props {
+			if !required[name] || !strictSchemaNode(prop) {
+				return false
+			}
+		}
+	}
+
+	// Nested schemas: array items, variants and definitions
+	if items, ok := schema["items"]; ok && !strictSchemaNode(items) {
+		return false
+	}
+	if variants, ok := schema["anyOf"]; ok && !strictSchemaNode(variants) {
+		return false
+	}
+	for _, key := range []string{"$defs", "definitions"} {
+		defs, _ := schema[key].(map[string]interface{})
+		for _, d := range defs {
+			if !strictSchemaNode(d) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func rowToSession(row sqlc.ChatSession) *domain.ChatSession {
-	return &domain.ChatSession{
-		ID:          row.ID,
-		UserID:      row.UserID,
-		Model:       row.Model,
-		Temperature: decimalToFloat(row.Temperature),
-		CreatedAt:   pgTimestamptzToTime(row.CreatedAt),
-		UpdatedAt:   pgTimestamptzToTime(row.UpdatedAt),
+	session := &domain.ChatSession{
+		ID:              row.ID,
+		UserID:          row.UserID,
+		Model:           row.Model,
+		Temperature:     decimalToFloat(row.Temperature),
+		CreatedAt:       pgTimestamptzToTime(row.CreatedAt),
+		UpdatedAt:       pgTimestamptzToTime(row.UpdatedAt),
+		ParentSessionID: row.ParentSessionID,
+		ForkMessageID:   row.ForkMessageID,
+	}
+	if err := json.Unmarshal(row.Params, &session.Params); err != nil {
+		slog.Error("decode session params", "session", row.ID, "error", err)
+	}
+	return session
+}
+
+func rowToSessionMessage(row sqlc.SessionMessage) domain.SessionMessage {
+	msg := domain.SessionMessage{
+		ID:                 row.ID,
+		SessionID:          row.SessionID,
+		Role:               row.Role,
+		Text:               row.Text,
+		Images:             row.Images,
+		IsSystem:           row.IsSystem,
+		CreatedAt:          pgTimestamptzToTime(row.CreatedAt),
+		ToolCallID:         row.ToolCallID,
+		IsSummary:          row.IsSummary,
+		TelegramMessageIDs: row.TgMessageIds,
+	}
+	if len(row.ToolCalls) > 0 {
+		var calls []ToolCall
+		if err := json.Unmarshal(row.ToolCalls, &calls); err == nil {
+			for _, c := range calls {
+				msg.ToolCalls = append(msg.ToolCalls, domain.ToolCall{
+					ID:        c.ID,
+					Name:      c.Function.Name,
+					Arguments: c.Function.Arguments,
+				})
+			}
+		}
+	}
+	return msg
+}
+
+// SessionChatMessages converts stored history into chat messages. Without tools
+// the tool exchanges are left out, since models that cannot call functions
+// reject them; any text the model wrote alongside its calls is kept.
+func SessionChatMessages(history []domain.SessionMessage, withTools bool) []ChatMessage {
+	msgs := make([]ChatMessage, 0, len(history))
+	for _, m := range history {
+		if !withTools && (m.Role == "tool" || len(m.ToolCalls) > 0) {
+			if m.Role == "assistant" && m.Text != "" {
+				msgs = append(msgs, ChatMessage{Role: m.Role, Content: m.Text})
+			}
+			continue
+		}
+		msg := ChatMessage{
+			Role:       m.Role,
+			Content:    m.Text,
+			ToolCallID: m.ToolCallID,
+		}
+		for _, c := range m.ToolCalls {
+			call := ToolCall{ID: c.ID, Type: "function"}
+			call.Function.Name = c.Name
+			call.Function.Arguments = c.Arguments
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		msgs = append(msgs, msg)
 	}
+	return msgs
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+func TestStrictSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   bool
+	}{
+		{"strict object", `{"type":"object","properties":{"a":{"type":"string"}},"required":["a"],"additionalProperties":false}`, true},
+		{"no properties", `{"type":"object","additionalProperties":false}`, true},
+		{"scalar", `{"type":"string"}`, true},
+		{"additional properties allowed", `{"type":"object","properties":{"a":{"type":"string"}},"required":["a"]}`, false},
+		{"optional property", `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"number"}},"required":["a"],"additionalProperties":false}`, false},
+		{"loose nested object", `{"type":"object","properties":{"a":{"type":"object","properties":{"b":{"type":"string"}}}},"required":["a"],"additionalProperties":false}`, false},
+		{"loose array items", `{"type":"array","items":{"type":"object","properties":{"b":{"type":"string"}},"required":["b"]}}`, false},
+		{"strict array items", `{"type":"array","items":{"type":"object","properties":{"b":{"type":"string"}},"required":["b"],"additionalProperties":false}}`, true},
+		{"loose variant", `{"anyOf":[{"type":"string"},{"type":"object","properties":{"b":{"type":"string"}}}]}`, false},
+		{"loose definition", `{"$defs":{"x":{"type":"object"}},"type":"string"}`, false},
+		{"invalid JSON", `{"type":`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StrictSchema(json.RawMessage(tt.schema)); got != tt.want {
+				t.Errorf("StrictSchema(%s) = %v, want %v", tt.schema, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplySessionParams(t *testing.T) {
+	topP, maxTokens := 0.5, 100
+	req := ApplySessionParams(ChatRequest{Model: "m"}, domain.SessionParams{
+		TopP:      &topP,
+		MaxTokens: &maxTokens,
+		Stop:      []string{"###"},
+	})
+	if req.TopP != &topP || req.MaxTokens != &maxTokens || len(req.Stop) != 1 || req.ResponseFormat != nil {
+		t.Errorf("ApplySessionParams() = %+v, want the session parameters and no response format", req)
+	}
+
+	req = ApplySessionParams(ChatRequest{}, domain.SessionParams{ResponseFormat: "json_object"})
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_object" || req.ResponseFormat.JSONSchema != nil {
+		t.Errorf("json_object: ResponseFormat = %+v", req.ResponseFormat)
+	}
+
+	for _, tt := range []struct {
+		schema string
+		strict bool
+	}{
+		{`{"type":"object","properties":{"a":{"type":"string"}},"required":["a"],"additionalProperties":false}`, true},
+		{`{"type":"object","properties":{"a":{"type":"string"}}}`, false},
+	} {
+		req = ApplySessionParams(ChatRequest{}, domain.SessionParams{
+			ResponseFormat: "json_schema",
+			JSONSchema:     json.RawMessage(tt.schema),
+		})
+		f := req.ResponseFormat
+		if f == nil || f.Type != "json_schema" || f.JSONSchema == nil || f.JSONSchema.Strict != tt.strict {
+			t.Errorf("json_schema %s: ResponseFormat = %+v, want strict %v", tt.schema, f, tt.strict)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+)
+
+const summaryPrompt = `You compress chat histories. Summarize the conversation below between a user and an AI assistant so the assistant can continue it without the original messages. Keep facts about the user, decisions, open questions, code, numbers and names that may matter later; drop small talk. Write in the language of the conversation, as concise notes, at most 400 words. Reply with the summary only.`
+
+// summaryHeader starts the stored summary so the model knows what it is reading.
+const summaryHeader = "Summary of the earlier part of this conversation:\n\n"
+
+// ErrNothingToSummarize is returned when a session is too short to compress.
+var ErrNothingToSummarize = errors.New("nothing to summarize")
+
+// SummaryService compresses the oldest part of a long session into a summary
+// message, so the conversation can go on instead of being reset.
+type SummaryService struct {
+	db      *pgxpool.Pool
+	queries *sqlc.Queries
+	llm     *LLMRouter
+	model   string
+}
+
+func NewSummaryService(db *pgxpool.Pool, queries *sqlc.Queries, llm *LLMRouter, model string) *SummaryService {
+	return &SummaryService{db: db, queries: queries, llm: llm, model: model}
+}
+
+// Summarize replaces all but the last keep messages of the session with a
+// summary and archives the originals. System prompts stay as they are; an
+// earlier summary is folded into the new one. It returns the response usage
+// and the model that did the work, for billing.
+func (s *SummaryService) Summarize(ctx context.Context, session *domain.ChatSession, keep int) (ChatUsage, *domain.AIModel, error) {
+	rows, err := s.queries.GetSessionMessages(ctx, session.ID)
+	if err != nil {
+		return ChatUsage{}, nil, fmt.Errorf("get session messages: %w", err)
+	}
+
+	var candidates []domain.SessionMessage
+	for _, row := range rows {
+		m := rowToSessionMessage(row)
+		if m.IsSystem && !m.IsSummary {
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+
+	// Cut at the start of a turn so tool calls stay with their results
+	cut := len(candidates) - keep
+	for cut > 0 && cut < len(candidates) && candidates[cut].Role != "user" {
+		cut++
+	}
+	if cut <= 0 || cut >= len(candidates) {
+		return ChatUsage{}, nil, ErrNothingToSummarize
+	}
+	old := candidates[:cut]
+
+	model, err := s.llm.GetModel(ctx, s.model)
+	if err != nil {
+		return ChatUsage{}, nil, fmt.Errorf("get summary model: %w", err)
+	}
+
+	temperature := 0.2
+	resp, err := s.llm.Chat(ctx, ChatRequest{
+		Model: model.ID,
+		Messages: []ChatMessage{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: summaryTranscript(old)},
+		},
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return ChatUsage{}, nil, fmt.Errorf("summarize: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return resp.Usage, model, fmt.Errorf("summarize: empty response")
+	}
+	summary := summaryHeader + strings.TrimSpace(resp.Choices[0].Message.Content)
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return resp.Usage, model, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.queries.WithTx(tx)
+
+	// The summary takes the place of the last message it covers
+	if _, err := qtx.AddSummarySessionMessage(ctx, sqlc.AddSummarySessionMessageParams{
+		SessionID: session.ID,
+		Role:      SystemRole(session.Model),
+		Text:      summary,
+		CreatedAt: timeToPgTimestamptz(old[len(old)-1].CreatedAt),
+	}); err != nil {
+		return resp.Usage, model, fmt.Errorf("add summary: %w", err)
+	}
+
+	ids := make([]int64, len(old))
+	for i, m := range old {
+		ids[i] = m.ID
+	}
+	if err := qtx.ArchiveSessionMessages(ctx, sqlc.ArchiveSessionMessagesParams{
+		SessionID: session.ID,
+		Ids:       ids,
+	}); err != nil {
+		return resp.Usage, model, fmt.Errorf("archive messages: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return resp.Usage, model, fmt.Errorf("commit: %w", err)
+	}
+	return resp.Usage, model, nil
+}
+
+// summaryTranscript renders messages as a plain dialogue. Tool exchanges are
+// left out and very long messages are shortened.
+func summaryTranscript(msgs []domain.SessionMessage) string {
+	var sb strings.Builder
+	for _, m := range msgs {
+		text := strings.TrimSpace(m.Text)
+		if text == "" || m.Role == "tool" {
+			continue
+		}
+		if runes := []rune(text); len(runes) > config.SummaryMessageMaxLen {
+			text = string(runes[:config.SummaryMessageMaxLen]) + " [...]"
+		}
+
+		switch {
+		case m.IsSummary:
+			sb.WriteString(strings.TrimPrefix(text, summaryHeader))
+		case m.Role == "assistant":
+			sb.WriteString("Assistant: " + text)
+		default:
+			sb.WriteString("User: " + text)
+		}
+		sb.WriteString("\n\n")
+	}
+	return sb.String()
+}
+
+// SystemRole is the role system prompts are stored with. Gemini models do not
+// support the "system" role, so they get the prompt as a user message.
+func SystemRole(model string) string {
+	if strings.Contains(strings.ToLower(model), "gemini") {
+		return "user"
+	}
+	return "system"
+}
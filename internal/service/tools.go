@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+)
+
+// Tool is a Go function the model can call during an answer.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON Schema of the arguments object.
+	Parameters map[string]interface{}
+	Run        func(ctx context.Context, user *domain.User, args json.RawMessage) (string, error)
+}
+
+// ToolRegistry holds the tools advertised to tool-capable models and runs the
+// call/result loop around chat requests.
+type ToolRegistry struct {
+	tools map[string]Tool
+	order []string
+}
+
+func NewToolRegistry(cfg *config.Config, queries *sqlc.Queries) *ToolRegistry {
+	r := &ToolRegistry{tools: make(map[string]Tool)}
+	r.Register(calculatorTool())
+	r.Register(dateTimeTool())
+	r.Register(currencyTool(cfg.CurrencyAPIURL))
+	r.Register(fetchURLTool())
+	r.Register(transactionsTool(queries))
+	return r
+}
+
+func (r *ToolRegistry) Register(t Tool) {
+	if _, ok := r.tools[t.Name]; !ok {
+		r.order = append(r.order, t.Name)
+	}
+	r.tools[t.Name] = t
+}
+
+// Definitions returns the tools in the form sent with chat requests.
+func (r *ToolRegistry) Definitions() []ToolDefinition {
+	defs := make([]ToolDefinition, 0, len(r.order))
+	for _, name := range r.order {
+		t := r.tools[name]
+		defs = append(defs, ToolDefinition{
+			Type: "function",
+			Function: ToolFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	return defs
+}
+
+// Execute runs a single tool call. Failures are returned as text so the model
+// can see what went wrong and answer anyway.
+func (r *ToolRegistry) Execute(ctx context.Context, user *domain.User, call ToolCall) string {
+	t, ok := r.tools[call.Function.Name]
+	if !ok {
+		return fmt.Sprintf("Error: unknown tool %q", call.Function.Name)
+	}
+
+	args := json.RawMessage(call.Function.Arguments)
+	if strings.TrimSpace(call.Function.Arguments) == "" {
+		args = json.RawMessage("{}")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.ToolCallTimeout)
+	defer cancel()
+
+	result, err := t.Run(ctx, user, args)
+	if err != nil {
+		slog.Warn("tool call failed", "tool", t.Name, "error", err)
+		return "Error: " + err.Error()
+	}
+	return truncateToolResult(result)
+}
+
+// ToolChatFunc sends one chat request, e.g. FallbackService.ChatStream with the
+// stream callback bound.
+type ToolChatFunc func(ctx context.Context, req ChatRequest) (*ChatResponse, *domain.AIModel, error)
+
+// RunWithTools sends req with the registered tools and executes the calls the
+// model makes until it answers with text. It returns the final response with
+// usage summed over all round-trips, the model that answered, and the tool
+// call and result messages to store before the answer. onToolCall, if set, is
+// called before each tool runs.
+func (r *ToolRegistry) RunWithTools(ctx context.Context, user *domain.User, req ChatRequest, chat ToolChatFunc, onToolCall func(call ToolCall)) (*ChatResponse, *domain.AIModel, []ChatMessage, error) {
+	req.Tools = r.Definitions()
+	req.Messages = append([]ChatMessage(nil), req.Messages...)
+
+	var exchanged []ChatMessage
+	var usage ChatUsage
+	for i := 0; ; i++ {
+		// Out of iterations: ask for a final answer without further calls
+		if i == config.MaxToolIterations {
+			req.ToolChoice = "none"
+		}
+
+		resp, usedModel, err := chat(ctx, req)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.CompletionTokens += resp.Usage.CompletionTokens
+		usage.TotalCost += resp.Usage.TotalCost
+		usage.CompletionTokensDetails.ReasoningTokens += resp.Usage.CompletionTokensDetails.ReasoningTokens
+		usage.PromptTokensDetails.CachedTokens += resp.Usage.PromptTokensDetails.CachedTokens
+		usage.PromptTokensDetails.CacheWriteTokens += resp.Usage.PromptTokensDetails.CacheWriteTokens
+		usage.GenerationIDs = append(usage.GenerationIDs, resp.Usage.GenerationIDs...)
+		resp.Usage = usage
+
+		if len(resp.Choices) == 0 || len(resp.Choices[0].Message.ToolCalls) == 0 || i == config.MaxToolIterations {
+			return resp, usedModel, exchanged, nil
+		}
+
+		// Keep talking to the model that asked for the tools
+		req.Model = usedModel.ID
+
+		calls := resp.Choices[0].Message.ToolCalls
+		for j := range calls {
+			if calls[j].ID == "" {
+				calls[j].ID = fmt.Sprintf("call_%d_%d", i, j)
+			}
+		}
+		assistant := ChatMessage{
+			Role:      "assistant",
+			Content:   resp.Choices[0].Message.Content,
+			ToolCalls: calls,
+		}
+		req.Messages = append(req.Messages, assistant)
+		exchanged = append(exchanged, assistant)
+
+		for _, call := range calls {
+			if onToolCall != nil {
+				onToolCall(call)
+			}
+			result := ChatMessage{
+				Role:       "tool",
+				Content:    r.Execute(ctx, user, call),
+				ToolCallID: call.ID,
+			}
+			req.Messages = append(req.Messages, result)
+			exchanged = append(exchanged, result)
+		}
+	}
+}
+
+func truncateToolResult(s string) string {
+	if utf8.RuneCountInString(s) <= config.ToolResultMaxLen {
+		return s
+	}
+	return string([]rune(s)[:config.ToolResultMaxLen]) + "\n[truncated]"
+}
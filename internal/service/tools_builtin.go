@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/set-night/mindapp/internal/domain"
+	"github.com/set-night/mindapp/internal/repository/sqlc"
+)
+
+func dateTimeTool() Tool {
+	return Tool{
+		Name:        "current_datetime",
+		Description: "Get the current date, time and weekday, optionally in a given IANA time zone.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"timezone": map[string]interface{}{
+					"type":        "string",
+					"description": "IANA time zone, e.g. \"Europe/Moscow\". Defaults to UTC.",
+				},
+			},
+		},
+		Run: func(ctx context.Context, user *domain.User, args json.RawMessage) (string, error) {
+			var params struct {
+				Timezone string `json:"timezone"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			loc := time.UTC
+			if params.Timezone != "" {
+				l, err := time.LoadLocation(params.Timezone)
+				if err != nil {
+					return "", fmt.Errorf("unknown time zone %q", params.Timezone)
+				}
+				loc = l
+			}
+			now := time.Now().In(loc)
+			return fmt.Sprintf("%s (%s, %s)", now.Format(time.RFC3339), now.Weekday(), loc), nil
+		},
+	}
+}
+
+// exchangeRates caches rate tables per base currency.
+type exchangeRates struct {
+	mu      sync.Mutex
+	tables  map[string]map[string]float64
+	fetched map[string]time.Time
+}
+
+func currencyTool(apiURL string) Tool {
+	rates := &exchangeRates{
+		tables:  make(map[string]map[string]float64),
+		fetched: make(map[string]time.Time),
+	}
+	client := &http.Client{Timeout: config.ToolCallTimeout}
+
+	return Tool{
+		Name:        "convert_currency",
+		Description: "Convert an amount between currencies at the current exchange rate. Uses ISO 4217 codes such as USD, EUR, RUB.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"amount": map[string]interface{}{"type": "number"},
+				"from":   map[string]interface{}{"type": "string", "description": "Source currency code"},
+				"to":     map[string]interface{}{"type": "string", "description": "Target currency code"},
+			},
+			"required": []string{"amount", "from", "to"},
+		},
+		Run: func(ctx context.Context, user *domain.User, args json.RawMessage) (string, error) {
+			var params struct {
+				Amount float64 `json:"amount"`
+				From   string  `json:"from"`
+				To     string  `json:"to"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			from := strings.ToUpper(strings.TrimSpace(params.From))
+			to := strings.ToUpper(strings.TrimSpace(params.To))
+
+			table, err := rates.get(ctx, client, apiURL, from)
+			if err != nil {
+				return "", err
+			}
+			rate, ok := table[to]
+			if !ok {
+				return "", fmt.Errorf("unknown currency %q", to)
+			}
+			return fmt.Sprintf("%.4f %s = %.4f %s (rate %.6f)", params.Amount, from, params.Amount*rate, to, rate), nil
+		},
+	}
+}
+
+func (r *exchangeRates) get(ctx context.Context, client *http.Client, apiURL, base string) (map[string]float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if table, ok := r.tables[base]; ok && time.Since(r.fetched[base]) < config.CurrencyRatesCacheDuration {
+		return table, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL+"/"+url.PathEscape(base), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Result string             `json:"result"`
+		Rates  map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("parse rates: %w", err)
+	}
+	if result.Result != "success" || len(result.Rates) == 0 {
+		return nil, fmt.Errorf("unknown currency %q", base)
+	}
+
+	r.tables[base] = result.Rates
+	r.fetched[base] = time.Now()
+	return result.Rates, nil
+}
+
+var errPrivateAddress = errors.New("private network addresses are not allowed")
+
+func fetchURLTool() Tool {
+	// Refuse to connect to internal addresses so the model cannot be used to
+	// probe the bot's own network
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+				ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+	client := &http.Client{
+		Timeout:   config.ToolCallTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, Proxy: nil},
+	}
+
+	return Tool{
+		Name:        "fetch_url",
+		Description: "Download a web page and return its readable text. Use for links the user sends or to read a specific page.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{"type": "string", "description": "http or https URL"},
+			},
+			"required": []string{"url"},
+		},
+		Run: func(ctx context.Context, user *domain.User, args json.RawMessage) (string, error) {
+			var params struct {
+				URL string `json:"url"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			u, err := url.Parse(params.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return "", fmt.Errorf("only http and https URLs are supported")
+			}
+
+			req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+			if err != nil {
+				return "", fmt.Errorf("create request: %w", err)
+			}
+			req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; MindAppBot/1.0)")
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", fmt.Errorf("fetch: %w", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode >= 400 {
+				return "", fmt.Errorf("server returned %s", resp.Status)
+			}
+			body, err := io.ReadAll(io.LimitReader(resp.Body, config.FetchURLMaxBytes))
+			if err != nil {
+				return "", fmt.Errorf("read body: %w", err)
+			}
+
+			if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+				return string(body), nil
+			}
+			return htmlToText(string(body))
+		},
+	}
+}
+
+// htmlToText extracts the title and visible text of an HTML page.
+func htmlToText(page string) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return "", fmt.Errorf("parse html: %w", err)
+	}
+	doc.Find("script, style, noscript, svg, nav, footer, header, form").Remove()
+
+	var sb strings.Builder
+	if title := strings.TrimSpace(doc.Find("title").First().Text()); title != "" {
+		sb.WriteString(title + "\n\n")
+	}
+	for _, line := range strings.Split(doc.Find("body").Text(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+func transactionsTool(queries *sqlc.Queries) Tool {
+	return Tool{
+		Name:        "my_transactions",
+		Description: "Get the user's current balance in USD and their most recent balance transactions (AI request charges, top-ups, promo codes).",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of transactions, 1-50. Defaults to 10.",
+				},
+			},
+		},
+		Run: func(ctx context.Context, user *domain.User, args json.RawMessage) (string, error) {
+			var params struct {
+				Limit int `json:"limit"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			if params.Limit <= 0 {
+				params.Limit = 10
+			}
+			if params.Limit > 50 {
+				params.Limit = 50
+			}
+
+			rows, err := queries.GetUserTransactions(ctx, sqlc.GetUserTransactionsParams{
+				UserID: &user.ID,
+				Limit:  int32(params.Limit),
+			})
+			if err != nil {
+				return "", fmt.Errorf("get transactions: %w", err)
+			}
+
+			var sb strings.Builder
+			sb.WriteString(fmt.Sprintf("Balance: $%s\n", user.Balance.StringFixed(4)))
+			if len(rows) == 0 {
+				sb.WriteString("No transactions.")
+				return sb.String(), nil
+			}
+			for _, t := range rows {
+				sb.WriteString(fmt.Sprintf("%s | %s | %s | %s\n",
+					pgTimestamptzToTime(t.CreatedAt).Format("2006-01-02 15:04"),
+					t.TxType, t.Amount.StringFixed(6), t.Description))
+			}
+			return sb.String(), nil
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvalExpression(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 / 4", 2.5},
+		{"10 % 4", 2},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"--3", 3},
+		{"1.5e3 + 2E-1", 1500.2},
+		{"sqrt(16) + pi", 4 + math.Pi},
+		{"  7  ", 7},
+	}
+	for _, tt := range tests {
+		got, err := evalExpression(tt.expr)
+		if err != nil {
+			t.Errorf("evalExpression(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("evalExpression(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvalExpressionErrors(t *testing.T) {
+	for _, expr := range []string{"", "1 / 0", "5 % 0", "(1 + 2", "1 +", "2 3", "foo(1)", "sqrt 4", "sqrt(-1)", "10 ^ 400", "1..2"} {
+		if got, err := evalExpression(expr); err == nil {
+			t.Errorf("evalExpression(%q) = %v, want an error", expr, got)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+const transcriptionPrompt = "Transcribe this audio verbatim in its original language. Reply with the transcript only, without comments or quotes. If there is no speech, reply with an empty message."
+
+// TranscriptionService turns voice messages into text with an audio-capable
+// chat model, for models that cannot take audio input themselves.
+type TranscriptionService struct {
+	llm   *LLMRouter
+	model string
+}
+
+func NewTranscriptionService(llm *LLMRouter, model string) *TranscriptionService {
+	return &TranscriptionService{llm: llm, model: model}
+}
+
+// Transcribe returns the recognized text together with the response usage and
+// the model that did the work, for billing.
+func (s *TranscriptionService) Transcribe(ctx context.Context, audio []byte, format string) (string, ChatUsage, *domain.AIModel, error) {
+	model, err := s.llm.GetModel(ctx, s.model)
+	if err != nil {
+		return "", ChatUsage{}, nil, fmt.Errorf("get transcription model: %w", err)
+	}
+
+	temperature := 0.0
+	resp, err := s.llm.Chat(ctx, ChatRequest{
+		Model: model.ID,
+		Messages: []ChatMessage{{
+			Role: "user",
+			Content: []interface{}{
+				map[string]interface{}{"type": "text", "text": transcriptionPrompt},
+				AudioContentPart(audio, format),
+			},
+		}},
+		Temperature: &temperature,
+	})
+	if err != nil {
+		return "", ChatUsage{}, nil, fmt.Errorf("transcribe: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", resp.Usage, model, nil
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), resp.Usage, model, nil
+}
+
+// AudioContentPart builds an input_audio message part.
+func AudioContentPart(audio []byte, format string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "input_audio",
+		"input_audio": map[string]string{
+			"data":   base64.StdEncoding.EncodeToString(audio),
+			"format": format,
+		},
+	}
+}
+
+// AudioFormat derives the input_audio format from a Telegram file path.
+// Voice notes are Opus in an Ogg container and video notes are MP4.
+func AudioFormat(filePath string) string {
+	switch ext := strings.ToLower(strings.TrimPrefix(path.Ext(filePath), ".")); ext {
+	case "oga", "ogg", "opus":
+		return "ogg"
+	case "mp3", "wav", "flac", "aac", "m4a", "mp4", "aiff":
+		return ext
+	case "mpeg", "mpga":
+		return "mp3"
+	}
+	return "ogg"
+}
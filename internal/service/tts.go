@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/set-night/mindapp/internal/config"
+	"github.com/shopspring/decimal"
+)
+
+// TTSService synthesizes voice replies through an OpenAI-compatible
+// /audio/speech endpoint. Audio is requested as Opus, which Telegram accepts
+// as a voice message without re-encoding.
+type TTSService struct {
+	enabled    bool
+	apiKey     string
+	baseURL    string
+	model      string
+	voice      string
+	price      float64 // per 1M characters
+	httpClient *http.Client
+}
+
+func NewTTSService(cfg *config.Config) *TTSService {
+	return &TTSService{
+		enabled:    cfg.TTSEnabled,
+		apiKey:     cfg.TTSAPIKey,
+		baseURL:    cfg.TTSBaseURL,
+		model:      cfg.TTSModel,
+		voice:      cfg.TTSVoice,
+		price:      cfg.TTSPrice,
+		httpClient: &http.Client{Timeout: config.RequestTimeout},
+	}
+}
+
+func (s *TTSService) Enabled() bool {
+	return s.enabled
+}
+
+func (s *TTSService) IsFree() bool {
+	return s.price == 0
+}
+
+// Cost returns the price of synthesizing the given number of characters with markup.
+func (s *TTSService) Cost(chars int, markupPercent float64) decimal.Decimal {
+	baseCost := decimal.NewFromFloat(float64(chars) * s.price / 1_000_000)
+	markup := decimal.NewFromFloat(1 + markupPercent/100)
+	return baseCost.Mul(markup)
+}
+
+// Synthesize converts text to OGG/Opus audio. Long texts are split into
+// several clips, and anything past config.MaxTTSChunks clips is dropped.
+// It returns the clips and the number of characters synthesized.
+func (s *TTSService) Synthesize(ctx context.Context, text string) ([][]byte, int, error) {
+	chunks := splitForSpeech(stripMarkdown(text), config.MaxTTSChunkLen)
+	if len(chunks) > config.MaxTTSChunks {
+		chunks = chunks[:config.MaxTTSChunks]
+	}
+
+	var clips [][]byte
+	chars := 0
+	for _, chunk := range chunks {
+		clip, err := s.speech(ctx, chunk)
+		if err != nil {
+			return nil, 0, err
+		}
+		clips = append(clips, clip)
+		chars += utf8.RuneCountInString(chunk)
+	}
+	return clips, chars, nil
+}
+
+func (s *TTSService) speech(ctx context.Context, input string) ([]byte, error) {
+	payload, err := json.Marshal(map[string]string{
+		"model":           s.model,
+		"voice":           s.voice,
+		"input":           input,
+		"response_format": "opus",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/audio/speech", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("speech request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newUpstreamError("TTS", resp)
+	}
+	defer resp.Body.Close()
+
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read audio: %w", err)
+	}
+	return audio, nil
+}
+
+var (
+	mdCodeFence = regexp.MustCompile("(?s)```[a-zA-Z]*\n?(.*?)```")
+	mdLink      = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+	mdSymbols   = strings.NewReplacer("**", "", "__", "", "*", "", "`", "", "#", "", "~~", "", ">", "")
+)
+
+// stripMarkdown removes formatting that would otherwise be read aloud.
+func stripMarkdown(text string) string {
+	text = mdCodeFence.ReplaceAllString(text, "$1")
+	text = mdLink.ReplaceAllString(text, "$1")
+	return strings.TrimSpace(mdSymbols.Replace(text))
+}
+
+// splitForSpeech splits text into chunks of at most maxLen characters,
+// preferring paragraph and sentence boundaries.
+func splitForSpeech(text string, maxLen int) []string {
+	var chunks []string
+	for utf8.RuneCountInString(text) > maxLen {
+		runes := []rune(text)
+		head := string(runes[:maxLen])
+		cut := strings.LastIndex(head, "\n")
+		if cut < len(head)/2 {
+			cut = strings.LastIndexAny(head, ".!?")
+			if cut >= 0 {
+				cut++
+			}
+		}
+		if cut < len(head)/2 {
+			cut = len(head)
+		}
+		chunks = append(chunks, strings.TrimSpace(head[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitForSpeech(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		maxLen int
+		want   []string
+	}{
+		{"empty", "", 10, nil},
+		{"fits", "Short text.", 20, []string{"Short text."}},
+		{"paragraph", "First paragraph.\nSecond one here.", 20, []string{"First paragraph.", "Second one here."}},
+		{"sentence", "One two three. Four five six.", 20, []string{"One two three.", "Four five six."}},
+		{"early break ignored", "A.\nbcdefghijklmnopqrstuvwxyz", 10, []string{"A.\nbcdefgh", "ijklmnopqr", "stuvwxyz"}},
+		{"cyrillic hard cut", "абвгдеёжзийклмн", 6, []string{"абвгде", "ёжзийк", "лмн"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitForSpeech(tt.text, tt.maxLen)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitForSpeech() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitForSpeechKeepsText(t *testing.T) {
+	text := strings.Repeat("Это предложение для синтеза речи. ", 50)
+	chunks := splitForSpeech(text, 100)
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 100 {
+			t.Errorf("chunk %d has %d characters, want at most 100", i, n)
+		}
+		if !strings.HasSuffix(c, ".") {
+			t.Errorf("chunk %d = %q, want it to end at a sentence", i, c)
+		}
+	}
+	if got := strings.Join(chunks, " "); got != strings.TrimSpace(text) {
+		t.Errorf("chunks do not add up to the text:\n%q", got)
+	}
+}
+
+func TestStripMarkdown(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"**bold** and _plain_", "bold and _plain_"},
+		{"# Title\n> quote", "Title\n quote"},
+		{"see [the docs](https://example.com)", "see the docs"},
+		{"```go\nfmt.Println()\n```", "fmt.Println()"},
+		{"`code` ~~old~~", "code old"},
+	}
+	for _, tt := range tests {
+		if got := stripMarkdown(tt.text); got != tt.want {
+			t.Errorf("stripMarkdown(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/set-night/mindapp/internal/domain"
+)
+
+// UpstreamErrorKind classifies failures returned by an LLM provider.
+type UpstreamErrorKind string
+
+const (
+	UpstreamRateLimit     UpstreamErrorKind = "rate_limit"
+	UpstreamOverloaded    UpstreamErrorKind = "overloaded"
+	UpstreamContextLength UpstreamErrorKind = "context_length"
+	UpstreamModeration    UpstreamErrorKind = "moderation"
+	UpstreamAuth          UpstreamErrorKind = "auth"
+	UpstreamOther         UpstreamErrorKind = "other"
+)
+
+// UpstreamError is a failed response from an LLM provider.
+type UpstreamError struct {
+	Provider   string
+	Kind       UpstreamErrorKind
+	StatusCode int
+	RetryAfter time.Duration // zero if the provider did not ask for a delay
+	Message    string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("%s %s (%d): %s", e.Provider, e.Kind, e.StatusCode, e.Message)
+}
+
+// Is lets callers match upstream errors against the domain sentinels.
+func (e *UpstreamError) Is(target error) bool {
+	switch target {
+	case domain.ErrRateLimited:
+		return e.Kind == UpstreamRateLimit
+	case domain.ErrServiceUnavailable:
+		return e.Kind == UpstreamOverloaded
+	}
+	return false
+}
+
+// Retryable reports whether the same request may succeed if sent again later.
+func (e *UpstreamError) Retryable() bool {
+	return e.Kind == UpstreamRateLimit || e.Kind == UpstreamOverloaded
+}
+
+// upstreamErrorBody is the error envelope used by OpenRouter and OpenAI-compatible APIs.
+type upstreamErrorBody struct {
+	Error struct {
+		Code     interface{}            `json:"code"`
+		Message  string                 `json:"message"`
+		Type     string                 `json:"type"`
+		Metadata map[string]interface{} `json:"metadata"`
+	} `json:"error"`
+}
+
+// newUpstreamError builds an error from a non-successful HTTP response and closes its body.
+func newUpstreamError(provider string, resp *http.Response) *UpstreamError {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var parsed upstreamErrorBody
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &parsed) == nil && parsed.Error.Message != "" {
+		message = parsed.Error.Message
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+
+	e := classifyUpstreamError(provider, resp.StatusCode, message, parsed.Error.Type, parsed.Error.Metadata)
+	e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	return e
+}
+
+// newStreamError builds an error from an error event received mid-stream.
+func newStreamError(provider string, code interface{}, message string) *UpstreamError {
+	status := 0
+	switch c := code.(type) {
+	case float64:
+		status = int(c)
+	case string:
+		status, _ = strconv.Atoi(c)
+	}
+	return classifyUpstreamError(provider, status, message, "", nil)
+}
+
+func classifyUpstreamError(provider string, status int, message, errType string, metadata map[string]interface{}) *UpstreamError {
+	e := &UpstreamError{
+		Provider:   provider,
+		Kind:       UpstreamOther,
+		StatusCode: status,
+		Message:    message,
+	}
+
+	lower := strings.ToLower(message + " " + errType)
+	_, flagged := metadata["reasons"]
+
+	switch {
+	case status == http.StatusTooManyRequests:
+		e.Kind = UpstreamRateLimit
+	case strings.Contains(lower, "context length") || strings.Contains(lower, "context_length") ||
+		strings.Contains(lower, "maximum context") || strings.Contains(lower, "too many tokens"):
+		e.Kind = UpstreamContextLength
+	case flagged || strings.Contains(lower, "moderation") || strings.Contains(lower, "flagged"):
+		e.Kind = UpstreamModeration
+	case status == http.StatusUnauthorized || status == http.StatusPaymentRequired || status == http.StatusForbidden:
+		e.Kind = UpstreamAuth
+	case status >= 500 || status == 408:
+		e.Kind = UpstreamOverloaded
+	}
+	return e
+}
+
+// parseRetryAfter accepts both forms of the Retry-After header: seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
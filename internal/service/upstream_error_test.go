@@ -0,0 +1,66 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClassifyUpstreamError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		message  string
+		errType  string
+		metadata map[string]interface{}
+		want     UpstreamErrorKind
+	}{
+		{"rate limit", http.StatusTooManyRequests, "slow down", "", nil, UpstreamRateLimit},
+		{"context length", http.StatusBadRequest, "This model's maximum context length is 8192 tokens", "", nil, UpstreamContextLength},
+		{"context length type", http.StatusBadRequest, "bad request", "context_length_exceeded", nil, UpstreamContextLength},
+		{"moderation metadata", http.StatusForbidden, "rejected", "", map[string]interface{}{"reasons": []string{"violence"}}, UpstreamModeration},
+		{"moderation message", http.StatusBadRequest, "Input was flagged", "", nil, UpstreamModeration},
+		{"unauthorized", http.StatusUnauthorized, "invalid key", "", nil, UpstreamAuth},
+		{"out of credits", http.StatusPaymentRequired, "insufficient credits", "", nil, UpstreamAuth},
+		{"server error", http.StatusBadGateway, "bad gateway", "", nil, UpstreamOverloaded},
+		{"timeout", http.StatusRequestTimeout, "timeout", "", nil, UpstreamOverloaded},
+		{"other", http.StatusBadRequest, "invalid parameter", "", nil, UpstreamOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyUpstreamError("test", tt.status, tt.message, tt.errType, tt.metadata)
+			if got.Kind != tt.want {
+				t.Errorf("Kind = %s, want %s", got.Kind, tt.want)
+			}
+			if got.StatusCode != tt.status || got.Message != tt.message {
+				t.Errorf("got status %d message %q, want %d %q", got.StatusCode, got.Message, tt.status, tt.message)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "5", 5 * time.Second, 5 * time.Second},
+		{"padded seconds", " 3 ", 3 * time.Second, 3 * time.Second},
+		{"zero", "0", 0, 0},
+		{"negative", "-2", 0, 0},
+		{"garbage", "soon", 0, 0},
+		{"future date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 50 * time.Second, time.Minute},
+		{"past date", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
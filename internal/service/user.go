@@ -129,6 +129,10 @@ func rowToUser(row sqlc.User) *domain.User {
 		SendUserInfo:     row.SendUserInfo,
 		ContextEnabled:   row.ContextEnabled,
 		SessionTimeoutMs: int(row.SessionTimeoutMs),
+		VoiceReplies:     row.VoiceReplies,
+		ShowReasoning:    row.ShowReasoning,
+		ReasoningEffort:  row.ReasoningEffort,
+		MaxRequestCost:   row.MaxRequestCost,
 		LastSkysmart:     pgTimestamptzToTime(row.LastSkysmart),
 		CreatedAt:        pgTimestamptzToTime(row.CreatedAt),
 		UpdatedAt:        pgTimestamptzToTime(row.UpdatedAt),
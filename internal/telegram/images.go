@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// maxMediaGroup is the largest album Telegram accepts.
+const maxMediaGroup = 10
+
+// SendImages sends images as a single photo or as albums. Each image is either
+// an http(s) URL or a base64 data URL. It returns the Telegram file IDs of the
+// sent photos in order.
+func SendImages(ctx context.Context, b *bot.Bot, chatID int64, images []string) ([]string, error) {
+	if len(images) == 1 {
+		params := &bot.SendPhotoParams{
+			ChatID: chatID,
+		}
+		if strings.HasPrefix(images[0], "data:") {
+			data, err := decodeDataURL(images[0])
+			if err != nil {
+				return nil, err
+			}
+			params.Photo = &models.InputFileUpload{Filename: "image.png", Data: bytes.NewReader(data)}
+		} else {
+			params.Photo = &models.InputFileString{Data: images[0]}
+		}
+		msg, err := b.SendPhoto(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("send photo: %w", err)
+		}
+		return []string{largestPhotoID(msg)}, nil
+	}
+
+	var fileIDs []string
+	for start := 0; start < len(images); start += maxMediaGroup {
+		end := min(start+maxMediaGroup, len(images))
+
+		media := make([]models.InputMedia, 0, end-start)
+		for i, img := range images[start:end] {
+			photo := &models.InputMediaPhoto{Media: img}
+			if strings.HasPrefix(img, "data:") {
+				data, err := decodeDataURL(img)
+				if err != nil {
+					return fileIDs, err
+				}
+				name := fmt.Sprintf("image%d.png", start+i)
+				photo.Media = "attach://" + name
+				photo.MediaAttachment = bytes.NewReader(data)
+			}
+			media = append(media, photo)
+		}
+
+		msgs, err := b.SendMediaGroup(ctx, &bot.SendMediaGroupParams{
+			ChatID: chatID,
+			Media:  media,
+		})
+		if err != nil {
+			return fileIDs, fmt.Errorf("send media group: %w", err)
+		}
+		for _, msg := range msgs {
+			fileIDs = append(fileIDs, largestPhotoID(msg))
+		}
+	}
+	return fileIDs, nil
+}
+
+// decodeDataURL returns the payload of a base64 data URL.
+func decodeDataURL(dataURL string) ([]byte, error) {
+	_, payload, ok := strings.Cut(dataURL, ";base64,")
+	if !ok {
+		return nil, fmt.Errorf("unsupported data URL")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	return data, nil
+}
+
+func largestPhotoID(msg *models.Message) string {
+	if msg == nil || len(msg.Photo) == 0 {
+		return ""
+	}
+	return msg.Photo[len(msg.Photo)-1].FileID
+}
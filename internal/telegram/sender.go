@@ -3,8 +3,10 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"html"
 	"log/slog"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -46,30 +48,55 @@ func SendLongMessage(ctx context.Context, b *bot.Bot, chatID int64, text string,
 	return nil
 }
 
-// EditLongMessage edits a message with potentially long text.
-func EditLongMessage(ctx context.Context, b *bot.Bot, chatID int64, messageID int, text string) error {
+// EditLongMessage edits a message with potentially long text. A nil
+// replyMarkup removes the message's inline keyboard.
+func EditLongMessage(ctx context.Context, b *bot.Bot, chatID int64, messageID int, text string, replyMarkup models.ReplyMarkup) error {
 	text = FixMarkdown(text)
 	if len([]rune(text)) > MaxMessageLen {
 		text = string([]rune(text)[:MaxMessageLen-3]) + "..."
 	}
 
 	_, err := b.EditMessageText(ctx, &bot.EditMessageTextParams{
-		ChatID:    chatID,
-		MessageID: messageID,
-		Text:      text,
-		ParseMode: models.ParseModeMarkdownV1,
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ParseMode:   models.ParseModeMarkdownV1,
+		ReplyMarkup: replyMarkup,
 	})
 	if err != nil {
 		// Fallback to plain text
 		_, err = b.EditMessageText(ctx, &bot.EditMessageTextParams{
-			ChatID:    chatID,
-			MessageID: messageID,
-			Text:      text,
+			ChatID:      chatID,
+			MessageID:   messageID,
+			Text:        text,
+			ReplyMarkup: replyMarkup,
 		})
 	}
 	return err
 }
 
+// SendExpandableQuote sends text as a collapsed quote under a bold title. The
+// text is cut to fit into a single message.
+func SendExpandableQuote(ctx context.Context, b *bot.Bot, chatID int64, title, text string, replyToID *int) error {
+	limit := MaxMessageLen - utf8.RuneCountInString(title) - 2
+	if runes := []rune(text); len(runes) > limit {
+		text = string(runes[:limit-1]) + "…"
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:    chatID,
+		Text:      fmt.Sprintf("<b>%s</b>\n<blockquote expandable>%s</blockquote>", html.EscapeString(title), html.EscapeString(text)),
+		ParseMode: models.ParseModeHTML,
+	}
+	if replyToID != nil {
+		params.ReplyParameters = &models.ReplyParameters{MessageID: *replyToID}
+	}
+	if _, err := b.SendMessage(ctx, params); err != nil {
+		return fmt.Errorf("send quote: %w", err)
+	}
+	return nil
+}
+
 // StartTyping sends "typing..." action every 4 seconds until the returned cancel function is called.
 func StartTyping(ctx context.Context, b *bot.Bot, chatID int64) context.CancelFunc {
 	ctx, cancel := context.WithCancel(ctx)
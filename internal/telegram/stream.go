@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// streamCursor is appended to the message while the answer is still being generated.
+const streamCursor = " ▌"
+
+// MessageStream progressively renders streamed text into Telegram messages.
+// Edits are throttled to one per interval, and once the text outgrows
+// MaxMessageLen the current message is finalized and a new one is started.
+type MessageStream struct {
+	b        *bot.Bot
+	chatID   int64
+	interval time.Duration
+
+	messageIDs   []int
+	text         strings.Builder
+	offset       int // byte offset in text where the current message starts
+	lastEdit     time.Time
+	lastRendered string
+	replyMarkup  models.ReplyMarkup // kept under the message while generating
+}
+
+// NewMessageStream creates a stream that starts by editing the given message.
+func NewMessageStream(b *bot.Bot, chatID int64, messageID int, interval time.Duration) *MessageStream {
+	return &MessageStream{
+		b:          b,
+		chatID:     chatID,
+		interval:   interval,
+		messageIDs: []int{messageID},
+	}
+}
+
+// SetReplyMarkup keeps an inline keyboard, such as a cancel button, under
+// the message being edited. Finish removes it.
+func (s *MessageStream) SetReplyMarkup(replyMarkup models.ReplyMarkup) {
+	s.replyMarkup = replyMarkup
+}
+
+// Append adds a piece of generated text and refreshes the message if the
+// throttle interval has passed since the last edit.
+func (s *MessageStream) Append(ctx context.Context, delta string) {
+	s.text.WriteString(delta)
+	if err := s.spill(ctx); err != nil {
+		return
+	}
+	if time.Since(s.lastEdit) < s.interval {
+		return
+	}
+	s.render(ctx, s.current()+streamCursor, s.replyMarkup)
+}
+
+// Finish renders the final text without the cursor.
+func (s *MessageStream) Finish(ctx context.Context) error {
+	if err := s.spill(ctx); err != nil {
+		return err
+	}
+	return s.render(ctx, s.current(), nil)
+}
+
+// Reset drops the text received so far, for example an intermediate answer
+// that ended in tool calls, and deletes the continuation messages so the next
+// text starts again in the first one.
+func (s *MessageStream) Reset(ctx context.Context) {
+	for _, id := range s.messageIDs[1:] {
+		s.b.DeleteMessage(ctx, &bot.DeleteMessageParams{ChatID: s.chatID, MessageID: id})
+	}
+	s.messageIDs = s.messageIDs[:1]
+	s.text.Reset()
+	s.offset = 0
+	s.lastRendered = ""
+}
+
+// Len returns the number of bytes received so far.
+func (s *MessageStream) Len() int {
+	return s.text.Len()
+}
+
+// MessageIDs returns the IDs of all messages used by the stream, in order.
+func (s *MessageStream) MessageIDs() []int {
+	return s.messageIDs
+}
+
+// LastMessageID returns the ID of the message currently being edited.
+func (s *MessageStream) LastMessageID() int {
+	return s.messageIDs[len(s.messageIDs)-1]
+}
+
+func (s *MessageStream) current() string {
+	return s.text.String()[s.offset:]
+}
+
+// spill finalizes full messages and moves the rest of the text to new ones.
+func (s *MessageStream) spill(ctx context.Context) error {
+	for utf8.RuneCountInString(s.current()) > MaxMessageLen {
+		part := SplitMessage(s.current(), MaxMessageLen)[0]
+		if err := s.render(ctx, part, nil); err != nil {
+			return err
+		}
+
+		msg, err := s.b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: s.chatID,
+			Text:   "…",
+		})
+		if err != nil {
+			return fmt.Errorf("send continuation message: %w", err)
+		}
+		s.messageIDs = append(s.messageIDs, msg.ID)
+		s.offset += len(part)
+		s.lastRendered = ""
+	}
+	return nil
+}
+
+func (s *MessageStream) render(ctx context.Context, text string, replyMarkup models.ReplyMarkup) error {
+	if strings.TrimSpace(text) == "" || text == s.lastRendered {
+		return nil
+	}
+	s.lastEdit = time.Now()
+	if err := EditLongMessage(ctx, s.b, s.chatID, s.LastMessageID(), text, replyMarkup); err != nil {
+		return err
+	}
+	s.lastRendered = text
+	return nil
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS model_fallbacks;
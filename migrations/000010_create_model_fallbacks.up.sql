@@ -0,0 +1,5 @@
+CREATE TABLE model_fallbacks (
+    model_id        TEXT PRIMARY KEY,
+    fallback_models TEXT[] NOT NULL DEFAULT '{}',
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
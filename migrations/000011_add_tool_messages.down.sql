@@ -0,0 +1,8 @@
+DELETE FROM session_messages WHERE role = 'tool' OR tool_calls IS NOT NULL;
+
+ALTER TABLE session_messages DROP COLUMN IF EXISTS tool_call_id;
+ALTER TABLE session_messages DROP COLUMN IF EXISTS tool_calls;
+
+ALTER TABLE session_messages DROP CONSTRAINT IF EXISTS session_messages_role_check;
+ALTER TABLE session_messages ADD CONSTRAINT session_messages_role_check
+    CHECK (role IN ('user','assistant','system'));
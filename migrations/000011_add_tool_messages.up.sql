@@ -0,0 +1,6 @@
+ALTER TABLE session_messages DROP CONSTRAINT IF EXISTS session_messages_role_check;
+ALTER TABLE session_messages ADD CONSTRAINT session_messages_role_check
+    CHECK (role IN ('user','assistant','system','tool'));
+
+ALTER TABLE session_messages ADD COLUMN tool_calls JSONB;
+ALTER TABLE session_messages ADD COLUMN tool_call_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN voice_replies BOOLEAN NOT NULL DEFAULT FALSE;
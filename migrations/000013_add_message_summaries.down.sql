@@ -0,0 +1,3 @@
+ALTER TABLE session_messages
+    DROP COLUMN IF EXISTS is_summary,
+    DROP COLUMN IF EXISTS archived;
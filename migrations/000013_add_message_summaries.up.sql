@@ -0,0 +1,3 @@
+ALTER TABLE session_messages
+    ADD COLUMN archived   BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN is_summary BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS reasoning_effort,
+    DROP COLUMN IF EXISTS show_reasoning;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN show_reasoning   BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN reasoning_effort TEXT NOT NULL DEFAULT '' CHECK (reasoning_effort IN ('', 'low', 'medium', 'high'));
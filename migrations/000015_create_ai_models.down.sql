@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS model_price_history;
+DROP TABLE IF EXISTS ai_models;
@@ -0,0 +1,28 @@
+CREATE TABLE ai_models (
+    id               TEXT PRIMARY KEY,
+    provider         TEXT NOT NULL,
+    data             JSONB NOT NULL,
+    prompt_price     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    completion_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+    image_price      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    request_price    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    available        BOOLEAN NOT NULL DEFAULT TRUE,
+    first_seen_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    removed_at       TIMESTAMPTZ
+);
+
+CREATE INDEX idx_ai_models_provider ON ai_models(provider);
+
+CREATE TABLE model_price_history (
+    id               BIGSERIAL PRIMARY KEY,
+    model_id         TEXT NOT NULL REFERENCES ai_models(id) ON DELETE CASCADE,
+    prompt_price     DOUBLE PRECISION NOT NULL,
+    completion_price DOUBLE PRECISION NOT NULL,
+    image_price      DOUBLE PRECISION NOT NULL,
+    request_price    DOUBLE PRECISION NOT NULL,
+    changed_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_model_price_history_model_id ON model_price_history(model_id, id);
+CREATE INDEX idx_model_price_history_changed_at ON model_price_history(changed_at);
@@ -0,0 +1,5 @@
+CREATE TABLE model_replacements (
+    model_id    TEXT PRIMARY KEY,
+    replacement TEXT NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN max_request_cost NUMERIC(20,10) NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE chat_sessions ADD COLUMN params JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE session_messages DROP COLUMN IF EXISTS tg_message_ids;
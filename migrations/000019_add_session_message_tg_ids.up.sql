@@ -0,0 +1 @@
+ALTER TABLE session_messages ADD COLUMN tg_message_ids BIGINT[] NOT NULL DEFAULT '{}';
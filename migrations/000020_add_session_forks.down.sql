@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_session_messages_tg_message_ids;
+
+ALTER TABLE chat_sessions
+    DROP COLUMN IF EXISTS fork_message_id,
+    DROP COLUMN IF EXISTS parent_session_id;
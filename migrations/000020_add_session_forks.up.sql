@@ -0,0 +1,5 @@
+ALTER TABLE chat_sessions
+    ADD COLUMN parent_session_id BIGINT REFERENCES chat_sessions(id) ON DELETE SET NULL,
+    ADD COLUMN fork_message_id   BIGINT REFERENCES session_messages(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_session_messages_tg_message_ids ON session_messages USING GIN (tg_message_ids);
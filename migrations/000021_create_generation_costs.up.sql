@@ -0,0 +1,19 @@
+CREATE TABLE generation_costs (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT REFERENCES users(id) ON DELETE CASCADE,
+    group_id       BIGINT REFERENCES groups(id) ON DELETE CASCADE,
+    model          TEXT NOT NULL,
+    generation_ids TEXT[] NOT NULL,
+    markup_percent DOUBLE PRECISION NOT NULL,
+    estimated_cost NUMERIC(20,10) NOT NULL,
+    charged        NUMERIC(20,10) NOT NULL,
+    actual_cost    NUMERIC(20,10) NOT NULL DEFAULT 0,
+    correction     NUMERIC(20,10) NOT NULL DEFAULT 0,
+    status         TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending','reconciled','failed')),
+    attempts       INTEGER NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reconciled_at  TIMESTAMPTZ
+);
+
+CREATE INDEX idx_generation_costs_pending ON generation_costs(created_at) WHERE status = 'pending';
+CREATE INDEX idx_generation_costs_reconciled_at ON generation_costs(reconciled_at);
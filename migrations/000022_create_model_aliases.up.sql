@@ -0,0 +1,9 @@
+CREATE TABLE model_aliases (
+    user_id    BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    alias      TEXT NOT NULL,
+    model_id   TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, alias)
+);
+
+CREATE INDEX idx_model_aliases_model_id ON model_aliases(model_id);
@@ -0,0 +1,47 @@
+-- name: ListAvailableAIModels :many
+SELECT * FROM ai_models WHERE available ORDER BY id ASC;
+
+-- name: UpsertAIModel :exec
+INSERT INTO ai_models (id, provider, data, prompt_price, completion_price, image_price, request_price)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (id) DO UPDATE SET
+    provider = EXCLUDED.provider,
+    data = EXCLUDED.data,
+    prompt_price = EXCLUDED.prompt_price,
+    completion_price = EXCLUDED.completion_price,
+    image_price = EXCLUDED.image_price,
+    request_price = EXCLUDED.request_price,
+    available = TRUE,
+    last_seen_at = NOW(),
+    removed_at = NULL;
+
+-- name: MarkAIModelsRemoved :many
+UPDATE ai_models SET available = FALSE, removed_at = NOW()
+WHERE provider = $1 AND available AND NOT (id = ANY(@ids::text[]))
+RETURNING id;
+
+-- name: AddModelPriceHistory :exec
+INSERT INTO model_price_history (model_id, prompt_price, completion_price, image_price, request_price)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetRecentPriceChanges :many
+SELECT h.model_id, h.prompt_price, h.completion_price, h.image_price, h.request_price, h.changed_at,
+       p.prompt_price AS old_prompt_price, p.completion_price AS old_completion_price,
+       p.image_price AS old_image_price, p.request_price AS old_request_price
+FROM model_price_history h
+JOIN LATERAL (
+    SELECT prev.prompt_price, prev.completion_price, prev.image_price, prev.request_price
+    FROM model_price_history prev
+    WHERE prev.model_id = h.model_id AND prev.id < h.id
+    ORDER BY prev.id DESC
+    LIMIT 1
+) p ON TRUE
+WHERE h.changed_at > $1
+ORDER BY h.changed_at DESC
+LIMIT $2;
+
+-- name: GetRemovedAIModels :many
+SELECT id, removed_at FROM ai_models
+WHERE NOT available AND removed_at > $1
+ORDER BY removed_at DESC
+LIMIT $2;
@@ -0,0 +1,40 @@
+-- name: CreateGenerationCost :exec
+INSERT INTO generation_costs (user_id, group_id, model, generation_ids, markup_percent, estimated_cost, charged)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListPendingGenerationCosts :many
+SELECT * FROM generation_costs
+WHERE status = 'pending' AND created_at < $1
+ORDER BY id ASC
+LIMIT $2;
+
+-- name: ReconcileGenerationCost :exec
+UPDATE generation_costs
+SET status = 'reconciled', actual_cost = $2, correction = $3, attempts = attempts + 1, reconciled_at = NOW()
+WHERE id = $1;
+
+-- name: RetryGenerationCost :exec
+UPDATE generation_costs
+SET attempts = attempts + 1,
+    status = CASE WHEN attempts + 1 >= @max_attempts::int THEN 'failed' ELSE status END
+WHERE id = @id;
+
+-- name: GetGenerationCostDrift :one
+SELECT COUNT(*) FILTER (WHERE status = 'reconciled') AS reconciled,
+       COUNT(*) FILTER (WHERE correction <> 0) AS corrected,
+       COUNT(*) FILTER (WHERE status = 'pending') AS pending,
+       COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+       COALESCE(SUM(estimated_cost) FILTER (WHERE status = 'reconciled'), 0)::numeric AS estimated,
+       COALESCE(SUM(actual_cost) FILTER (WHERE status = 'reconciled'), 0)::numeric AS actual,
+       COALESCE(SUM(correction) FILTER (WHERE correction > 0), 0)::numeric AS charged_extra,
+       COALESCE(SUM(-correction) FILTER (WHERE correction < 0), 0)::numeric AS refunded
+FROM generation_costs
+WHERE created_at > $1;
+
+-- name: GetGenerationCostDriftByModel :many
+SELECT model, COUNT(*) AS generations, SUM(actual_cost - estimated_cost)::numeric AS drift
+FROM generation_costs
+WHERE status = 'reconciled' AND created_at > $1
+GROUP BY model
+ORDER BY ABS(SUM(actual_cost - estimated_cost)) DESC
+LIMIT $2;
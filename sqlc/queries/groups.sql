@@ -62,3 +62,8 @@ WHERE id IN (
     ORDER BY gcm.created_at ASC
     LIMIT $2
 );
+
+-- name: ReplaceGroupModel :many
+UPDATE groups SET selected_model = @new_model::text, updated_at = NOW()
+WHERE selected_model = @old_model::text
+RETURNING telegram_id, thread_id;
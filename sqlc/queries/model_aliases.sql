@@ -0,0 +1,20 @@
+-- name: CountUserModelAliases :one
+SELECT COUNT(*) FROM model_aliases WHERE user_id = $1;
+
+-- name: DeleteModelAlias :execrows
+DELETE FROM model_aliases WHERE user_id = $1 AND alias = $2;
+
+-- name: GetModelAlias :one
+SELECT model_id FROM model_aliases WHERE user_id = $1 AND alias = $2;
+
+-- name: ListUserModelAliases :many
+SELECT * FROM model_aliases WHERE user_id = $1 ORDER BY alias ASC;
+
+-- name: ReplaceAliasModel :execrows
+UPDATE model_aliases SET model_id = @new_model::text
+WHERE model_id = @old_model::text;
+
+-- name: SetModelAlias :exec
+INSERT INTO model_aliases (user_id, alias, model_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, alias) DO UPDATE SET model_id = EXCLUDED.model_id;
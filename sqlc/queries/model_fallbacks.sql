@@ -0,0 +1,14 @@
+-- name: GetModelFallbacks :one
+SELECT fallback_models FROM model_fallbacks WHERE model_id = $1;
+
+-- name: SetModelFallbacks :exec
+INSERT INTO model_fallbacks (model_id, fallback_models, updated_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (model_id)
+DO UPDATE SET fallback_models = EXCLUDED.fallback_models, updated_at = NOW();
+
+-- name: DeleteModelFallbacks :exec
+DELETE FROM model_fallbacks WHERE model_id = $1;
+
+-- name: ListModelFallbacks :many
+SELECT * FROM model_fallbacks ORDER BY model_id ASC;
@@ -0,0 +1,21 @@
+-- name: GetModelReplacement :one
+SELECT replacement FROM model_replacements WHERE model_id = $1;
+
+-- name: SetModelReplacement :exec
+INSERT INTO model_replacements (model_id, replacement, updated_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (model_id)
+DO UPDATE SET replacement = EXCLUDED.replacement, updated_at = NOW();
+
+-- name: DeleteModelReplacement :exec
+DELETE FROM model_replacements WHERE model_id = $1;
+
+-- name: ListModelReplacements :many
+SELECT * FROM model_replacements ORDER BY model_id ASC;
+
+-- name: ListReferencedModels :many
+SELECT selected_model AS model_id FROM users
+UNION SELECT unnest(favorite_models) FROM users
+UNION SELECT selected_model FROM groups
+UNION SELECT model FROM chat_sessions
+UNION SELECT model_id FROM model_aliases;
@@ -12,9 +12,20 @@ INSERT INTO chat_sessions (user_id, model, temperature)
 VALUES ($1, $2, $3)
 RETURNING *;
 
+-- name: CreateForkSession :one
+INSERT INTO chat_sessions (user_id, model, temperature, params, parent_session_id, fork_message_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
 -- name: UpdateSessionModel :exec
 UPDATE chat_sessions SET model = $2, updated_at = NOW() WHERE id = $1;
 
+-- name: UpdateSessionTemperature :exec
+UPDATE chat_sessions SET temperature = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: UpdateSessionParams :exec
+UPDATE chat_sessions SET params = $2, updated_at = NOW() WHERE id = $1;
+
 -- name: UpdateSessionTimestamp :exec
 UPDATE chat_sessions SET updated_at = NOW() WHERE id = $1;
 
@@ -38,11 +49,47 @@ INSERT INTO session_messages (session_id, role, text, images, is_system)
 VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
+-- name: AddToolSessionMessage :one
+INSERT INTO session_messages (session_id, role, text, tool_calls, tool_call_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: AddSummarySessionMessage :one
+INSERT INTO session_messages (session_id, role, text, is_system, is_summary, created_at)
+VALUES ($1, $2, $3, TRUE, TRUE, $4)
+RETURNING *;
+
+-- name: ArchiveSessionMessages :exec
+UPDATE session_messages SET archived = TRUE
+WHERE session_id = $1 AND id = ANY(@ids::bigint[]);
+
+-- name: DeleteSessionMessages :exec
+DELETE FROM session_messages
+WHERE session_id = $1 AND id = ANY(@ids::bigint[]);
+
+-- name: UpdateSessionMessageText :exec
+UPDATE session_messages SET text = $2 WHERE id = $1;
+
+-- name: SetSessionMessageTelegramIDs :exec
+UPDATE session_messages SET tg_message_ids = $2 WHERE id = $1;
+
 -- name: GetSessionMessages :many
-SELECT * FROM session_messages WHERE session_id = $1 ORDER BY created_at ASC;
+SELECT * FROM session_messages WHERE session_id = $1 AND NOT archived ORDER BY created_at ASC;
+
+-- name: GetUserSessionMessage :one
+SELECT sm.* FROM session_messages sm
+JOIN chat_sessions cs ON cs.id = sm.session_id
+WHERE sm.id = $1 AND cs.user_id = $2;
+
+-- name: GetUserAnswerByTelegramID :one
+SELECT sm.* FROM session_messages sm
+JOIN chat_sessions cs ON cs.id = sm.session_id
+WHERE cs.user_id = @user_id AND sm.role = 'assistant' AND sm.tg_message_ids @> ARRAY[@tg_message_id::bigint]
+ORDER BY sm.id DESC
+LIMIT 1;
 
 -- name: CountSessionMessages :one
-SELECT COUNT(*) FROM session_messages WHERE session_id = $1;
+SELECT COUNT(*) FROM session_messages WHERE session_id = $1 AND NOT archived;
 
 -- name: GetFirstSessionMessage :one
 SELECT * FROM session_messages WHERE session_id = $1 ORDER BY created_at ASC LIMIT 1;
@@ -50,5 +97,20 @@ SELECT * FROM session_messages WHERE session_id = $1 ORDER BY created_at ASC LIM
 -- name: AddMessageFile :exec
 INSERT INTO message_files (message_id, file_type, url, name) VALUES ($1, $2, $3, $4);
 
+-- name: CopySessionMessage :one
+INSERT INTO session_messages (session_id, role, text, images, is_system, created_at, tool_calls, tool_call_id, is_summary)
+SELECT @session_id::bigint, role, text, images, is_system, created_at, tool_calls, tool_call_id, is_summary
+FROM session_messages WHERE id = @id::bigint
+RETURNING id;
+
+-- name: CopyMessageFiles :exec
+INSERT INTO message_files (message_id, file_type, url, name)
+SELECT @new_message_id::bigint, file_type, url, name
+FROM message_files WHERE message_id = @message_id::bigint;
+
 -- name: GetMessageFiles :many
 SELECT * FROM message_files WHERE message_id = $1;
+
+-- name: ReplaceSessionModel :execrows
+UPDATE chat_sessions SET model = @new_model::text, updated_at = NOW()
+WHERE model = @old_model::text;
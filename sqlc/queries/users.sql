@@ -48,6 +48,18 @@ UPDATE users SET context_enabled = NOT context_enabled, updated_at = NOW() WHERE
 -- name: ToggleUserSendUserInfo :exec
 UPDATE users SET send_user_info = NOT send_user_info, updated_at = NOW() WHERE id = $1;
 
+-- name: ToggleUserVoiceReplies :exec
+UPDATE users SET voice_replies = NOT voice_replies, updated_at = NOW() WHERE id = $1;
+
+-- name: ToggleUserShowReasoning :exec
+UPDATE users SET show_reasoning = NOT show_reasoning, updated_at = NOW() WHERE id = $1;
+
+-- name: SetUserReasoningEffort :exec
+UPDATE users SET reasoning_effort = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: SetUserMaxRequestCost :exec
+UPDATE users SET max_request_cost = $2, updated_at = NOW() WHERE id = $1;
+
 -- name: SetUserSessionTimeout :exec
 UPDATE users SET session_timeout_ms = $2, updated_at = NOW() WHERE id = $1;
 
@@ -74,3 +86,15 @@ SELECT COUNT(*) FROM users WHERE created_at >= $1;
 
 -- name: CountPremiumUsers :one
 SELECT COUNT(*) FROM users WHERE premium_until IS NOT NULL AND premium_until > NOW();
+
+-- name: ReplaceUserModel :many
+UPDATE users SET
+    selected_model = CASE WHEN selected_model = @old_model::text THEN @new_model::text ELSE selected_model END,
+    favorite_models = CASE
+        WHEN NOT (@old_model::text = ANY(favorite_models)) THEN favorite_models
+        WHEN @new_model::text = ANY(favorite_models) THEN array_remove(favorite_models, @old_model::text)
+        ELSE array_replace(favorite_models, @old_model::text, @new_model::text)
+    END,
+    updated_at = NOW()
+WHERE selected_model = @old_model::text OR @old_model::text = ANY(favorite_models)
+RETURNING telegram_id;